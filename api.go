@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"strings"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -23,8 +25,20 @@ const (
 	// DefaultAutoprompt if true, your query will be converted to a Metaphor query.
 	DefaultAutoprompt = false
 
-	// DefaultSearchType is a string defining what type of search will be performed, "neural" or by "keyword".
-	DefaultSearchType = "neural"
+	// DefaultSearchType defines what type of search will be performed, "neural", "keyword" or "auto".
+	DefaultSearchType = SearchTypeNeural
+
+	// DefaultContentsBatchSize is the maximum number of IDs sent in a single contents request.
+	DefaultContentsBatchSize = 100
+
+	// DefaultContentsConcurrency is the maximum number of contents requests sent concurrently.
+	DefaultContentsConcurrency = 4
+
+	// DefaultContentsMethod is the HTTP method used to request contents.
+	DefaultContentsMethod = http.MethodGet
+
+	// DefaultStreamBufferSize is the number of items buffered by the streaming API.
+	DefaultStreamBufferSize = 100
 
 	//// DEFAULT API ENDPOINT URL's
 
@@ -42,63 +56,141 @@ const (
 )
 
 var (
-	ErrMissingApiKey = errors.New("missing the Metaphor API key, set it as the METAPHOR_API_KEY environment variable")
-	ErrRequestFailed = errors.New("request failed with error")
-	ErrSearchFailed = errors.New("search failed with error")
-	ErrFindSimilarLinkdFailed = errors.New("find similar links failed with error")
-	ErrGetContentsFailed = errors.New("get contents failed with error")
-	ErrNoSearchResults = errors.New("no search results were found")
-	ErrNoLinksFound = errors.New("no links were found")
-	ErrNoContentExtracted = errors.New("no content was extracted")
+	ErrMissingApiKey             = errors.New("missing the Metaphor API key, set it as the METAPHOR_API_KEY environment variable")
+	ErrRequestFailed             = errors.New("request failed with error")
+	ErrSearchFailed              = errors.New("search failed with error")
+	ErrFindSimilarLinkdFailed    = errors.New("find similar links failed with error")
+	ErrGetContentsFailed         = errors.New("get contents failed with error")
+	ErrCallRejected              = errors.New("call rejected by hook")
+	ErrNoAvailableKey            = errors.New("no API key available")
+	ErrNoSearchResults           = errors.New("no search results were found")
+	ErrNoLinksFound              = errors.New("no links were found")
+	ErrNoContentExtracted        = errors.New("no content was extracted")
+	ErrInvalidRequest            = errors.New("invalid request")
+	ErrMissingDate               = errors.New("missing date")
+	ErrInvalidDate               = errors.New("invalid date, use ISO 8601 format (YYYY-MM-DD or YYYY-MM-DDTHH:MM:SSZ)")
+	ErrInvalidDateRange          = errors.New("invalid date range, the start date must precede the end date")
+	ErrUnsupportedContentsMethod = errors.New("unsupported contents method, use GET or POST")
+	ErrInvalidConfig             = errors.New("invalid configuration")
 )
 
 type RequestBody struct {
-	Query               string   `json:"query,omitempty"`
-	URL                 string   `json:"url,omitempty"`
-	NumResults          int      `json:"numResults,omitempty"`
-	IncludeDomains      []string `json:"includeDomains,omitempty"`
-	ExcludeDomains      []string `json:"excludeDomains,omitempty"`
-	StartCrawlDate      string   `json:"startCrawlDate,omitempty"`
-	EndCrawlDate        string   `json:"endCrawlDate,omitempty"`
-	StartPublishedDate  string   `json:"startPublishedDate,omitempty"`
-	EndPublishedDate    string   `json:"endPublishedDate,omitempty"`
-	ExcludeSourceDomain bool     `json:"excludeSourceDomain,omitempty"`
-	UseAutoprompt       bool     `json:"useAutoprompt,omitempty"`
-	Type                string   `json:"type,omitempty"`
+	Query               string     `json:"query,omitempty"`
+	URL                 string     `json:"url,omitempty"`
+	NumResults          int        `json:"numResults,omitempty"`
+	IncludeDomains      []string   `json:"includeDomains,omitempty"`
+	ExcludeDomains      []string   `json:"excludeDomains,omitempty"`
+	StartCrawlDate      string     `json:"startCrawlDate,omitempty"`
+	EndCrawlDate        string     `json:"endCrawlDate,omitempty"`
+	StartPublishedDate  string     `json:"startPublishedDate,omitempty"`
+	EndPublishedDate    string     `json:"endPublishedDate,omitempty"`
+	ExcludeSourceDomain bool       `json:"excludeSourceDomain,omitempty"`
+	UseAutoprompt       bool       `json:"useAutoprompt,omitempty"`
+	Type                SearchType `json:"type,omitempty"`
+
+	// Contents asks for the contents of the results to be returned inline.
+	// It is set by SearchAndContents and FindSimilarAndContents.
+	Contents *ContentsOptions `json:"contents,omitempty"`
 }
 
+// Client is a Metaphor API client. A Client is safe for concurrent use by
+// multiple goroutines: its fields are only written by NewClient, and every
+// call builds its own request from the client defaults and the per-call
+// options.
 type Client struct {
-	apiKey      string
-	options     []ClientOptions
-	BaseURL     string
-	RequestBody *RequestBody
+	keys       KeyProvider
+	options    []ClientOptions
+	httpClient *http.Client
+	limiter    *limiter
+	cache      Cache
+	store      Cache
+	cacheStats struct{ hits, misses, documentHits, documentMisses atomic.Int64 }
+
+	instrumentation Instrumentation
+
+	BaseURL string
+}
+
+// requestConfig holds the state of a single call. It is created fresh for
+// every Search, FindSimilar and GetContents call and is never shared.
+type requestConfig struct {
+	baseURL string
+	body    RequestBody
+
+	// idempotent reports whether the call can safely be sent more than once.
+	idempotent  bool
+	retryPolicy RetryPolicy
+	cacheMode   cacheMode
+
+	contentsBatchSize   int
+	contentsConcurrency int
+	contentsMethod      string
+	streamBufferSize    int
+	partialContents     bool
+	logger              *slog.Logger
+	logOptions          LogOptions
+	beforeRequestHooks  []BeforeRequestHook
+	afterResponseHooks  []AfterResponseHook
+
+	// header holds the headers set by hooks.
+	header http.Header
+
+	// The fields below configure the Client itself and are only read by
+	// NewClient.
+	httpClient  *http.Client
+	transport   http.RoundTripper
+	timeout     time.Duration
+	middlewares []Middleware
+	rateLimit   float64
+	rateBurst   int
+	maxInFlight int
+	cache       Cache
+	store       Cache
+	keyProvider KeyProvider
+
+	instrumentation Instrumentation
 }
 
 // NewClient creates a new MetaphorClient with the provided API key and options.
 //
 // Parameters:
-// - apiKey: The API key used for authentication.
+// - apiKey: The API key used for authentication, can be empty when WithKeyProvider is given.
 // - options: Optional client options that can be passed to customize the client.
 //
 // Returns:
 // - *MetaphorClient: A new instance of the MetaphorClient.
 // - error: An error if the client creation fails.
 func NewClient(apiKey string, options ...ClientOptions) (*Client, error) {
-	if apiKey == "" {
-		return nil, ErrMissingApiKey
+	config := &requestConfig{baseURL: DefaultBaseURL}
+	for _, option := range options {
+		option(config)
+	}
+
+	keys := config.keyProvider
+	if keys == nil {
+		if apiKey == "" {
+			return nil, ErrMissingApiKey
+		}
+		keys = StaticKey(apiKey)
 	}
 
 	client := &Client{
-		apiKey:      apiKey,
-		options:     options,
-		BaseURL:     DefaultBaseURL,
-		RequestBody: &RequestBody{},
+		keys:       keys,
+		options:    append([]ClientOptions(nil), options...),
+		httpClient: newHTTPClient(config),
+		limiter:    newLimiter(config.rateLimit, config.rateBurst, config.maxInFlight),
+		cache:      config.cache,
+		store:      config.store,
+		BaseURL:    config.baseURL,
+
+		instrumentation: config.instrumentation,
 	}
 
 	return client, nil
 }
 
-// Search searches for a given query using the Metaphor client.
+// Search searches for a given query using the Metaphor client. The request is
+// validated before being sent, see RequestBody.Validate.
 //
 // Parameters:
 // - ctx: The context.Context for the request.
@@ -108,29 +200,56 @@ func NewClient(apiKey string, options ...ClientOptions) (*Client, error) {
 // Returns:
 // - *SearchResponse: The search response object.
 // - error: An error if the search fails.
-func (client *Client) Search(ctx context.Context, query string, options ...ClientOptions) (*SearchResponse, error) {
-	searchResults := &SearchResponse{}
-	client.RequestBody = &RequestBody{
+func (client *Client) Search(ctx context.Context, query string, options ...ClientOptions) (searchResults *SearchResponse, err error) {
+	searchResults = &SearchResponse{}
+	config := client.newRequestConfig(RequestBody{
 		Query:         query,
 		NumResults:    DefaultNumResults,
 		UseAutoprompt: DefaultAutoprompt,
 		Type:          DefaultSearchType,
+	}, options)
+	config.idempotent = true
+
+	call := &Call{Operation: "Search", Endpoint: DefaultSearchPath, Body: &config.body}
+	if err := client.runBeforeRequestHooks(ctx, config, call); err != nil {
+		return searchResults, fmt.Errorf("%w: %w", ErrSearchFailed, err)
+	}
+
+	if config.body.Type == SearchTypeAuto {
+		config.body.Type = ChooseSearchType(config.body.Query)
 	}
 
-	client.loadOptions(options...)
+	ctx, endCall := client.startCall(ctx, CallInfo{
+		Operation:  "Search",
+		Endpoint:   DefaultSearchPath,
+		SearchType: config.body.Type,
+		NumResults: config.body.NumResults,
+	})
+	defer func() { endCall(searchResultCount(searchResults), err) }()
+	defer func() {
+		response := &CallResponse{Search: searchResults, Err: err}
+		if hookErr := client.runAfterResponseHooks(ctx, config, call, response); hookErr != nil {
+			err = fmt.Errorf("%w: %w", ErrSearchFailed, hookErr)
+		}
+	}()
+
+	if err := config.body.Validate(); err != nil {
+		return searchResults, fmt.Errorf("%w: %w", ErrSearchFailed, err)
+	}
 
-	reqBytes, err := json.Marshal(client.RequestBody)
+	reqBytes, err := json.Marshal(config.body)
 	if err != nil {
 		return searchResults, fmt.Errorf("%w: %w", ErrSearchFailed, err)
 	}
 
-	reqURL := client.BaseURL + DefaultSearchPath
+	reqURL := config.baseURL + DefaultSearchPath
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return searchResults, fmt.Errorf("%w: %w", ErrSearchFailed, err)
 	}
 
-	responseBody, err := client.runRequest(req)
+	cacheKey := requestCacheKey(config.baseURL, DefaultSearchPath, config.body)
+	responseBody, err := client.runCachedRequest(config, req, cacheKey)
 	if err != nil {
 		return searchResults, fmt.Errorf("%w: %w", ErrSearchFailed, err)
 	}
@@ -139,6 +258,7 @@ func (client *Client) Search(ctx context.Context, query string, options ...Clien
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrSearchFailed, err)
 	}
+	searchResults.Type = config.body.Type
 
 	if len(searchResults.Results) == 0 {
 		return searchResults, ErrNoSearchResults
@@ -147,7 +267,8 @@ func (client *Client) Search(ctx context.Context, query string, options ...Clien
 	return searchResults, nil
 }
 
-// FindSimilar searches for similar urls using the provided URL.
+// FindSimilar searches for similar urls using the provided URL. The request
+// is validated before being sent, see RequestBody.Validate.
 //
 // Parameters:
 // - ctx: The context.Context for the function.
@@ -157,29 +278,57 @@ func (client *Client) Search(ctx context.Context, query string, options ...Clien
 // Returns:
 // - *SearchResponse: The search response object.
 // - error: An error if the search fails.
-func (client *Client) FindSimilar(ctx context.Context, url string, options ...ClientOptions) (*SearchResponse, error) {
-	searchResults := &SearchResponse{}
-	client.RequestBody = &RequestBody{
-		URL:           			 url,
-		NumResults:    			 DefaultNumResults,
-		UseAutoprompt: 			 DefaultAutoprompt,
+func (client *Client) FindSimilar(ctx context.Context, url string, options ...ClientOptions) (searchResults *SearchResponse, err error) {
+	searchResults = &SearchResponse{}
+	config := client.newRequestConfig(RequestBody{
+		URL:                 url,
+		NumResults:          DefaultNumResults,
+		UseAutoprompt:       DefaultAutoprompt,
 		ExcludeSourceDomain: DefaultExcludeSourceDomain,
+	}, options)
+	config.idempotent = true
+
+	call := &Call{Operation: "FindSimilar", Endpoint: DefaultFindSimilarPath, Body: &config.body}
+	if err := client.runBeforeRequestHooks(ctx, config, call); err != nil {
+		return searchResults, fmt.Errorf("%w: %w", ErrFindSimilarLinkdFailed, err)
 	}
 
-	client.loadOptions(options...)
+	// SearchTypeAuto is chosen from the query, which FindSimilar has none of.
+	// It is resolved by the client and is never sent to the API.
+	if config.body.Type == SearchTypeAuto {
+		config.body.Type = ""
+	}
+
+	ctx, endCall := client.startCall(ctx, CallInfo{
+		Operation:  "FindSimilar",
+		Endpoint:   DefaultFindSimilarPath,
+		NumResults: config.body.NumResults,
+	})
+	defer func() { endCall(searchResultCount(searchResults), err) }()
+	defer func() {
+		response := &CallResponse{Search: searchResults, Err: err}
+		if hookErr := client.runAfterResponseHooks(ctx, config, call, response); hookErr != nil {
+			err = fmt.Errorf("%w: %w", ErrFindSimilarLinkdFailed, hookErr)
+		}
+	}()
+
+	if err := config.body.Validate(); err != nil {
+		return searchResults, fmt.Errorf("%w: %w", ErrFindSimilarLinkdFailed, err)
+	}
 
-	reqBytes, err := json.Marshal(client.RequestBody)
+	reqBytes, err := json.Marshal(config.body)
 	if err != nil {
 		return searchResults, fmt.Errorf("%w: %w", ErrFindSimilarLinkdFailed, err)
 	}
 
-	reqURL := client.BaseURL + DefaultFindSimilarPath
+	reqURL := config.baseURL + DefaultFindSimilarPath
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return searchResults, fmt.Errorf("%w: %w", ErrFindSimilarLinkdFailed, err)
 	}
 
-	responseBody, err := client.runRequest(req)
+	cacheKey := requestCacheKey(config.baseURL, DefaultFindSimilarPath, config.body)
+	responseBody, err := client.runCachedRequest(config, req, cacheKey)
 	if err != nil {
 		return searchResults, fmt.Errorf("%w: %w", ErrFindSimilarLinkdFailed, err)
 	}
@@ -197,36 +346,69 @@ func (client *Client) FindSimilar(ctx context.Context, url string, options ...Cl
 }
 
 // GetContents retrieves the contents of urls for the given set of IDs.
+// Large ID lists are split into batches fetched concurrently; when only some
+// batches fail, the contents of the others are returned alongside a
+// *ContentsBatchError.
 //
 // Parameters:
 // - ctx: the context.Context for the request.
 // - ids: a slice of strings containing the IDs to retrieve the contents for.
+// - options: Optional client options.
 //
 // Returns:
 // - *ContentsResponse: The contents response object.
 // - error: An error if the contents retrieval fails.
-func (client *Client) GetContents(ctx context.Context, ids []string) (*ContentsResponse, error) {
-	contentsResults := &ContentsResponse{}
-	
-	client.loadOptions()
+func (client *Client) GetContents(ctx context.Context, ids []string, options ...ClientOptions) (*ContentsResponse, error) {
+	config := client.newRequestConfig(RequestBody{}, options)
+	config.idempotent = true
 
-	joinedIds := strings.Join(ids, "\",\"")
+	return client.getContentsCall(ctx, config, ids)
+}
 
-	reqURL := client.BaseURL + DefaultContentsPath
+// getContentsCall runs a GetContents call with config: it runs the hooks,
+// instruments the call, validates it and fetches the contents of ids. It is
+// shared by GetContents and the contents batches of streams.
+func (client *Client) getContentsCall(ctx context.Context, config *requestConfig, ids []string) (contentsResults *ContentsResponse, err error) {
+	contentsResults = &ContentsResponse{}
 
-	URL := fmt.Sprintf("%s?ids=\"%s\"", reqURL, joinedIds)
+	call := &Call{
+		Operation: "GetContents",
+		Endpoint:  DefaultContentsPath,
+		Contents:  &ContentsRequestBody{IDs: ids, ContentsOptions: config.body.Contents},
+	}
+	if err := client.runBeforeRequestHooks(ctx, config, call); err != nil {
+		return contentsResults, fmt.Errorf("%w: %w", ErrGetContentsFailed, err)
+	}
+	ids = call.Contents.IDs
+	config.body.Contents = call.Contents.ContentsOptions
+
+	ctx, endCall := client.startCall(ctx, CallInfo{
+		Operation:  "GetContents",
+		Endpoint:   DefaultContentsPath,
+		NumResults: len(ids),
+	})
+	defer func() { endCall(len(contentsResults.Contents), err) }()
+	defer func() {
+		response := &CallResponse{Contents: contentsResults, Err: err}
+		if hookErr := client.runAfterResponseHooks(ctx, config, call, response); hookErr != nil {
+			err = fmt.Errorf("%w: %w", ErrGetContentsFailed, hookErr)
+		}
+	}()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL, nil)
-	if err != nil {
+	if err := (ContentsRequestBody{IDs: ids, ContentsOptions: config.body.Contents}).Validate(); err != nil {
 		return contentsResults, fmt.Errorf("%w: %w", ErrGetContentsFailed, err)
 	}
 
-	responseBody, err := client.runRequest(req)
-	if err != nil {
+	responseBody, err := client.getContents(ctx, config, ids)
+	if responseBody == nil {
 		return contentsResults, fmt.Errorf("%w: %w", ErrGetContentsFailed, err)
 	}
 
-	err = json.Unmarshal(responseBody, &contentsResults)
+	if unmarshalErr := json.Unmarshal(responseBody, &contentsResults); unmarshalErr != nil {
+		return contentsResults, fmt.Errorf("%w: %w", ErrGetContentsFailed, unmarshalErr)
+	}
+
+	// Some batches failed, return the contents of the others with the error.
 	if err != nil {
 		return contentsResults, fmt.Errorf("%w: %w", ErrGetContentsFailed, err)
 	}
@@ -238,22 +420,139 @@ func (client *Client) GetContents(ctx context.Context, ids []string) (*ContentsR
 	return contentsResults, nil
 }
 
+// runCachedRequest returns the cached response for key when the client has a
+// cache, and otherwise sends the request with runRequest and caches its
+// successful response.
+func (client *Client) runCachedRequest(config *requestConfig, req *http.Request, key string) ([]byte, error) {
+	if client.cache == nil || config.cacheMode == cacheBypass {
+		return client.runRequest(config, req)
+	}
+
+	ctx := req.Context()
+	if config.cacheMode != cacheRefresh {
+		if body, ok := client.cache.Get(ctx, key); ok {
+			client.cacheStats.hits.Add(1)
+			return body, nil
+		}
+		client.cacheStats.misses.Add(1)
+	}
+
+	body, err := client.runRequest(config, req)
+	if err != nil {
+		return nil, err
+	}
+
+	client.cache.Set(ctx, key, body)
+
+	return body, nil
+}
+
 // runRequest sends an HTTP request and returns the response body as a byte array.
+// Failed attempts are retried according to the retry policy of the call when
+// the call is idempotent.
 //
 // Parameters:
-// - ctx: the context.Context for the request.
+// - config: the configuration of the current call.
 // - req: the HTTP request to send
 //
 // Returns:
 // - []byte: the response body as a byte array
 // - error: an error if the request fails
-func (client *Client) runRequest(req *http.Request) ([]byte, error) {
-	req.Header.Add("x-api-key", client.apiKey)
+func (client *Client) runRequest(config *requestConfig, req *http.Request) (responseBody []byte, err error) {
+	attempts := 0
+	req, endRequest := client.startRequest(req)
+	defer func() { endRequest(attempts, err) }()
+
+	key, err := client.keys.Key(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("x-api-key", key)
 	req.Header.Add("accept", "application/json")
 	req.Header.Add("content-type", "application/json")
+	for name, values := range config.header {
+		if http.CanonicalHeaderKey(name) == "X-Api-Key" {
+			continue
+		}
+		req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+
+	log := newRequestLog(config, req)
+	log.request(req)
+
+	replayable := req.Body == nil || req.GetBody != nil
+	policy := config.retryPolicy
+	if !config.idempotent || !replayable {
+		policy = RetryPolicy{}
+	}
+
+	failover, _ := client.keys.(KeyFailover)
+	failovers := 0
+
+	for attempt := 1; ; attempt++ {
+		attempts++
+		attemptReq := req
+		if attempts > 1 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+
+			var keyErr error
+			if key, keyErr = client.keys.Key(req.Context()); keyErr != nil {
+				log.failure(attempts, keyErr)
+				return nil, keyErr
+			}
+			attemptReq.Header.Set("x-api-key", key)
+		}
+
+		body, err := client.sendRequest(attemptReq)
+		if err == nil {
+			log.response(attempts, body)
+			return body, nil
+		}
+
+		// A rejected key does not count against the retry policy, the
+		// request is sent again with another key right away.
+		if failover != nil && replayable && failovers < maxKeyFailovers &&
+			isKeyRejected(err) && failover.Failover(req.Context(), key, err) {
+			failovers++
+			attempt--
+			log.retry(attempts, err)
+			continue
+		}
+
+		if attempt >= policy.MaxAttempts || !policy.shouldRetry(req.Context(), err) {
+			log.failure(attempts, err)
+			return nil, err
+		}
+
+		log.retry(attempts, err)
+		if waitErr := policy.wait(req.Context(), attempt, err); waitErr != nil {
+			log.failure(attempts, err)
+			return nil, err
+		}
+	}
+}
+
+// sendRequest performs a single attempt of an HTTP request, waiting for the
+// client limiter first. Non-200 responses are returned as an *APIError.
+func (client *Client) sendRequest(req *http.Request) ([]byte, error) {
+	if client.limiter != nil {
+		release, err := client.limiter.acquire(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
 
 	// trunk-ignore(gokart/CWE-918:-Server-Side-Request-Forgery)
-	res, err := http.DefaultClient.Do(req)
+	res, err := client.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -265,25 +564,56 @@ func (client *Client) runRequest(req *http.Request) ([]byte, error) {
 	}
 
 	if res.StatusCode != http.StatusOK {
-		errorResponse := &ErrorResponse{}
-		err := json.Unmarshal(body, &errorResponse)
-		if err != nil {
-			return nil, err
-		}
-		errorTxt := errorResponse.Text
-
-		return nil, fmt.Errorf("%w: %s", ErrRequestFailed, errorTxt)
+		return nil, newAPIError(res, body)
 	}
 
 	return body, nil
 }
 
-func (client *Client) loadOptions(options ...ClientOptions) {
-	if len(options) > 0 {
-		client.options = options
+// LimiterStats returns a snapshot of the client rate limiter and concurrency
+// governor. It returns zero stats when neither WithRateLimit nor
+// WithMaxInFlight was passed to NewClient.
+func (client *Client) LimiterStats() LimiterStats {
+	if client.limiter == nil {
+		return LimiterStats{}
+	}
+
+	return client.limiter.stats()
+}
+
+// CacheStats returns the number of cache hits and misses of the client. It
+// returns zero stats when no cache was passed to NewClient with WithCache or
+// WithContentStore.
+func (client *Client) CacheStats() CacheStats {
+	return CacheStats{
+		Hits:           client.cacheStats.hits.Load(),
+		Misses:         client.cacheStats.misses.Load(),
+		DocumentHits:   client.cacheStats.documentHits.Load(),
+		DocumentMisses: client.cacheStats.documentMisses.Load(),
+	}
+}
+
+// newRequestConfig builds the state for a single call. The endpoint defaults
+// are applied first, then the options passed to NewClient and finally the
+// per-call options, so later options override earlier ones.
+func (client *Client) newRequestConfig(defaults RequestBody, options []ClientOptions) *requestConfig {
+	config := &requestConfig{
+		baseURL:             client.BaseURL,
+		body:                defaults,
+		contentsBatchSize:   DefaultContentsBatchSize,
+		contentsConcurrency: DefaultContentsConcurrency,
+		contentsMethod:      DefaultContentsMethod,
+		streamBufferSize:    DefaultStreamBufferSize,
+		logOptions:          DefaultLogOptions(),
 	}
 
 	for _, option := range client.options {
-		option(client)
+		option(config)
+	}
+
+	for _, option := range options {
+		option(config)
 	}
-}
\ No newline at end of file
+
+	return config
+}
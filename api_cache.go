@@ -0,0 +1,198 @@
+package metaphor
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Cache stores raw API responses so that repeated calls do not hit the
+// network. Keys are derived from the endpoint and a canonical form of the
+// request, see WithCache. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored for key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool)
+
+	// Set stores value for key.
+	Set(ctx context.Context, key string, value []byte)
+}
+
+// CacheStats counts the lookups made in the response cache configured with
+// WithCache and in the content store configured with WithContentStore.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+
+	// DocumentHits and DocumentMisses count the per-document lookups made in
+	// the content store.
+	DocumentHits   int64
+	DocumentMisses int64
+}
+
+// cacheMode controls how a single call uses the client cache.
+type cacheMode int
+
+const (
+	// cacheDefault reads from and writes to the cache.
+	cacheDefault cacheMode = iota
+
+	// cacheBypass neither reads from nor writes to the cache.
+	cacheBypass
+
+	// cacheRefresh skips the lookup but stores the fresh response.
+	cacheRefresh
+)
+
+// requestCacheKey returns the cache key of a search or find similar request.
+// Domain lists are sorted since their order does not change the results.
+func requestCacheKey(baseURL string, path string, body RequestBody) string {
+	body.IncludeDomains = sortedCopy(body.IncludeDomains)
+	body.ExcludeDomains = sortedCopy(body.ExcludeDomains)
+
+	// Marshaling a RequestBody cannot fail, it only holds strings, numbers
+	// and booleans.
+	canonical, _ := json.Marshal(body)
+
+	return hashCacheKey(baseURL, path, string(canonical))
+}
+
+// contentsCacheKey returns the cache key of a contents request. IDs are
+// sorted so that the same set of documents always maps to the same key, and
+// the contents options are part of the key when set.
+func contentsCacheKey(baseURL string, path string, ids []string, options *ContentsOptions) string {
+	canonical, _ := json.Marshal(sortedCopy(ids))
+	if options == nil {
+		return hashCacheKey(baseURL, path, string(canonical))
+	}
+
+	canonicalOptions, _ := json.Marshal(options)
+
+	return hashCacheKey(baseURL, path, string(canonical), string(canonicalOptions))
+}
+
+// documentCacheKey returns the content store key of a single document
+// fetched with the given contents options.
+func documentCacheKey(baseURL string, id string, options *ContentsOptions) string {
+	if options == nil {
+		return hashCacheKey(baseURL, DefaultContentsPath, "document", id)
+	}
+
+	canonical, _ := json.Marshal(options)
+
+	return hashCacheKey(baseURL, DefaultContentsPath, "document", id, string(canonical))
+}
+
+func hashCacheKey(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func sortedCopy(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// MemoryCache is an in-memory Cache evicting the least recently used entries
+// once full, and expiring entries after a fixed time to live.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates a MemoryCache.
+//
+// Parameters:
+// - maxEntries: the maximum number of entries kept, zero means no limit.
+// - ttl: how long entries are kept, zero means forever.
+//
+// Returns:
+// - *MemoryCache: A new, empty cache.
+func NewMemoryCache(maxEntries int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// Get implements Cache.
+func (cache *MemoryCache) Get(_ context.Context, key string) ([]byte, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+		return nil, false
+	}
+
+	cache.order.MoveToFront(element)
+
+	return entry.value, true
+}
+
+// Set implements Cache.
+func (cache *MemoryCache) Set(_ context.Context, key string, value []byte) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry := &memoryCacheEntry{key: key, value: value}
+	if cache.ttl > 0 {
+		entry.expires = time.Now().Add(cache.ttl)
+	}
+
+	if element, ok := cache.entries[key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(entry)
+
+	for cache.maxEntries > 0 && cache.order.Len() > cache.maxEntries {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of entries in the cache, including expired entries
+// that were not evicted yet.
+func (cache *MemoryCache) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.order.Len()
+}
@@ -0,0 +1,100 @@
+package metaphor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileCache is a Cache storing every entry as a file in a directory, so that
+// responses survive restarts and can be shared between processes. Entries
+// expire after a fixed time to live, based on the file modification time.
+type FileCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewFileCache creates a FileCache, creating the directory if needed.
+//
+// Parameters:
+// - dir: the directory holding the cache files.
+// - ttl: how long entries are kept, zero means forever.
+//
+// Returns:
+// - *FileCache: A new cache backed by dir.
+// - error: An error if the directory cannot be created.
+func NewFileCache(dir string, ttl time.Duration) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &FileCache{dir: dir, ttl: ttl}, nil
+}
+
+// Get implements Cache. Expired entries are removed when they are read.
+func (cache *FileCache) Get(_ context.Context, key string) ([]byte, bool) {
+	path := cache.path(key)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+
+	if cache.ttl > 0 && time.Since(info.ModTime()) > cache.ttl {
+		os.Remove(path)
+		return nil, false
+	}
+
+	value, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// Set implements Cache. Entries are written to a temporary file first and
+// renamed, so that concurrent readers never see a partial entry. Write
+// errors are ignored since a missing entry only causes a cache miss.
+func (cache *FileCache) Set(_ context.Context, key string, value []byte) {
+	file, err := os.CreateTemp(cache.dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(value)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return
+	}
+
+	os.Rename(file.Name(), cache.path(key))
+}
+
+// path returns the file holding key. Keys are hashed by the client so they
+// are safe to use as file names, other keys are hashed again.
+func (cache *FileCache) path(key string) string {
+	if !isHexKey(key) {
+		key = hashCacheKey(key)
+	}
+
+	return filepath.Join(cache.dir, key)
+}
+
+func isHexKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for _, char := range key {
+		if (char < '0' || char > '9') && (char < 'a' || char > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,174 @@
+package metaphor_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+func TestCacheServesRepeatedCalls(t *testing.T) {
+	server := newTestServer(t, 6)
+	client, err := server.NewClient(metaphor.WithCache(metaphor.NewMemoryCache(100, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	first, err := client.Search(ctx, "golang", metaphor.WithIncludeDomains([]string{"alpha.com", "beta.com"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The order of the domains does not change the cache key.
+	second, err := client.Search(ctx, "golang", metaphor.WithIncludeDomains([]string{"beta.com", "alpha.com"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := server.RequestCount(""); count != 1 {
+		t.Fatalf("got %d requests, want 1", count)
+	}
+
+	if len(first.Results) != len(second.Results) || first.Results[0].ID != second.Results[0].ID {
+		t.Fatalf("cached response differs: %+v and %+v", first.Results, second.Results)
+	}
+
+	if _, err := client.GetContents(ctx, []string{"id-1", "id-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetContents(ctx, []string{"id-2", "id-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := server.RequestCount(metaphor.DefaultContentsPath); count != 1 {
+		t.Fatalf("got %d contents requests, want 1", count)
+	}
+
+	if stats := client.CacheStats(); stats.Hits != 2 || stats.Misses != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCacheBypassAndRefresh(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithCache(metaphor.NewMemoryCache(100, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, options := range [][]metaphor.ClientOptions{
+		nil,
+		{metaphor.WithCacheBypass()},
+		{metaphor.WithCacheRefresh()},
+		nil,
+	} {
+		if _, err := client.Search(ctx, "golang", options...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := server.RequestCount(""); count != 3 {
+		t.Fatalf("got %d requests, want 3", count)
+	}
+}
+
+func TestCacheDoesNotStoreErrors(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithCache(metaphor.NewMemoryCache(100, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusInternalServerError))
+	if _, err := client.Search(context.Background(), "golang"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := server.RequestCount(""); count != 2 {
+		t.Fatalf("got %d requests, want 2", count)
+	}
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := metaphor.NewMemoryCache(2, 0)
+
+	cache.Set(ctx, "a", []byte("1"))
+	cache.Set(ctx, "b", []byte("2"))
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", []byte("3"))
+
+	if _, ok := cache.Get(ctx, "b"); ok {
+		t.Fatal("b should have been evicted")
+	}
+
+	if value, ok := cache.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Fatalf("got %q, %v for a", value, ok)
+	}
+
+	if cache.Len() != 2 {
+		t.Fatalf("got %d entries, want 2", cache.Len())
+	}
+}
+
+func TestMemoryCacheExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	cache := metaphor.NewMemoryCache(0, 10*time.Millisecond)
+
+	cache.Set(ctx, "a", []byte("1"))
+	if _, ok := cache.Get(ctx, "a"); !ok {
+		t.Fatal("a should be cached")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := cache.Get(ctx, "a"); ok {
+		t.Fatal("a should have expired")
+	}
+}
+
+func TestFileCache(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	cache, err := metaphor.NewFileCache(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Set(ctx, "not/a hex key", []byte("value"))
+	cache.Set(ctx, "0123abcd", []byte("other"))
+
+	// Entries survive across instances.
+	reopened, err := metaphor.NewFileCache(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value, ok := reopened.Get(ctx, "not/a hex key"); !ok || string(value) != "value" {
+		t.Fatalf("got %q, %v", value, ok)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "0123abcd"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := reopened.Get(ctx, "0123abcd"); ok {
+		t.Fatal("expired entry was returned")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "0123abcd")); !os.IsNotExist(err) {
+		t.Fatalf("expired entry was not removed: %v", err)
+	}
+}
@@ -0,0 +1,413 @@
+package metaphor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables read by ConfigFromEnv and NewClientFromEnv.
+const (
+	// EnvAPIKey holds the API key.
+	EnvAPIKey = "METAPHOR_API_KEY"
+
+	// EnvBaseURL holds the base URL of the API.
+	EnvBaseURL = "METAPHOR_BASE_URL"
+
+	// EnvConfig holds the path of a JSON configuration file, see LoadConfig.
+	EnvConfig = "METAPHOR_CONFIG"
+
+	// EnvTimeout holds the timeout of HTTP requests, such as "30s", or a
+	// number of seconds.
+	EnvTimeout = "METAPHOR_TIMEOUT"
+
+	// EnvNumResults holds the default number of search results.
+	EnvNumResults = "METAPHOR_NUM_RESULTS"
+
+	// EnvSearchType holds the default search type.
+	EnvSearchType = "METAPHOR_SEARCH_TYPE"
+
+	// EnvMaxAttempts holds the maximum number of attempts of the retry policy.
+	EnvMaxAttempts = "METAPHOR_MAX_ATTEMPTS"
+
+	// EnvCacheDir holds the directory of a FileCache.
+	EnvCacheDir = "METAPHOR_CACHE_DIR"
+
+	// EnvCacheTTL holds the time to live of cached responses, such as "1h",
+	// or a number of seconds.
+	EnvCacheTTL = "METAPHOR_CACHE_TTL"
+)
+
+// DefaultCacheMaxEntries is the size of the MemoryCache created from a
+// configuration that sets a cache TTL without a directory or a size.
+const DefaultCacheMaxEntries = 1000
+
+// Config is the configuration of a Client, loaded from a JSON file with
+// LoadConfig or from the environment with ConfigFromEnv. Unset fields keep
+// the values given in code, or the library defaults.
+//
+// An example configuration file:
+//
+//	{
+//		"baseURL": "https://api.metaphor.systems",
+//		"timeout": "30s",
+//		"search": {"numResults": 20, "type": "auto"},
+//		"retry": {"maxAttempts": 5, "baseDelay": "1s"},
+//		"cache": {"dir": "/var/cache/metaphor", "ttl": "24h"}
+//	}
+type Config struct {
+	// APIKey is the API key. It is best kept out of configuration files and
+	// set with the METAPHOR_API_KEY environment variable.
+	APIKey string `json:"apiKey,omitempty"`
+
+	// BaseURL is the base URL of the API, see WithBaseURL.
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// Timeout is the timeout of every HTTP request, see WithTimeout.
+	Timeout Duration `json:"timeout,omitempty"`
+
+	// Search holds the default options of every call, see WithRequestOptions.
+	Search *RequestOptions `json:"search,omitempty"`
+
+	// Retry configures the retry policy, see WithRetryPolicy.
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// Cache configures the response cache, see WithCache.
+	Cache *CacheConfig `json:"cache,omitempty"`
+}
+
+// RetryConfig configures a retry policy. Unset fields keep the values of the
+// retry policy given in code, or of DefaultRetryPolicy when none is given.
+type RetryConfig struct {
+	MaxAttempts          int      `json:"maxAttempts,omitempty"`
+	BaseDelay            Duration `json:"baseDelay,omitempty"`
+	MaxDelay             Duration `json:"maxDelay,omitempty"`
+	Jitter               *float64 `json:"jitter,omitempty"`
+	RetryableStatusCodes []int    `json:"retryableStatusCodes,omitempty"`
+	RetryNetworkErrors   *bool    `json:"retryNetworkErrors,omitempty"`
+}
+
+// CacheConfig configures the response cache: a FileCache when Dir is set,
+// and a MemoryCache otherwise.
+type CacheConfig struct {
+	// Dir is the directory of the FileCache.
+	Dir string `json:"dir,omitempty"`
+
+	// MaxEntries is the size of the MemoryCache, DefaultCacheMaxEntries if
+	// zero.
+	MaxEntries int `json:"maxEntries,omitempty"`
+
+	// TTL is the time to live of cached responses, zero means they never
+	// expire.
+	TTL Duration `json:"ttl,omitempty"`
+}
+
+// Duration is a time.Duration read from JSON as a string such as "1m30s",
+// or as a number of seconds.
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string.
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(duration).String())
+}
+
+// UnmarshalJSON decodes a duration string or a number of seconds.
+func (duration *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*duration = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string or a number of seconds, got %s", data)
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	*duration = Duration(parsed)
+
+	return nil
+}
+
+// LoadConfig reads a JSON configuration file. Unknown fields are rejected
+// to catch typos.
+//
+// Parameters:
+// - path: the path of the configuration file.
+//
+// Returns:
+// - Config: the configuration.
+// - error: an error wrapping ErrInvalidConfig if the file cannot be read or parsed.
+func LoadConfig(path string) (Config, error) {
+	config := Config{}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+	}
+
+	return config, nil
+}
+
+// ConfigFromEnv loads the configuration from the environment: the file named
+// by METAPHOR_CONFIG if set, overridden by the other METAPHOR_ environment
+// variables.
+//
+// Returns:
+// - Config: the configuration.
+// - error: an error wrapping ErrInvalidConfig if the file or a variable is invalid.
+func ConfigFromEnv() (Config, error) {
+	config := Config{}
+	if path := os.Getenv(EnvConfig); path != "" {
+		var err error
+		if config, err = LoadConfig(path); err != nil {
+			return config, err
+		}
+	}
+
+	if apiKey := os.Getenv(EnvAPIKey); apiKey != "" {
+		config.APIKey = apiKey
+	}
+
+	if baseURL := os.Getenv(EnvBaseURL); baseURL != "" {
+		config.BaseURL = baseURL
+	}
+
+	if err := envDuration(EnvTimeout, &config.Timeout); err != nil {
+		return config, err
+	}
+
+	if value := os.Getenv(EnvNumResults); value != "" {
+		numResults, err := envInt(EnvNumResults, value)
+		if err != nil {
+			return config, err
+		}
+		config.Search = withSearchConfig(config.Search)
+		config.Search.NumResults = numResults
+	}
+
+	if value := os.Getenv(EnvSearchType); value != "" {
+		config.Search = withSearchConfig(config.Search)
+		config.Search.Type = SearchType(value)
+	}
+
+	if value := os.Getenv(EnvMaxAttempts); value != "" {
+		maxAttempts, err := envInt(EnvMaxAttempts, value)
+		if err != nil {
+			return config, err
+		}
+		if config.Retry == nil {
+			config.Retry = &RetryConfig{}
+		}
+		config.Retry.MaxAttempts = maxAttempts
+	}
+
+	if dir := os.Getenv(EnvCacheDir); dir != "" {
+		if config.Cache == nil {
+			config.Cache = &CacheConfig{}
+		}
+		config.Cache.Dir = dir
+	}
+
+	if os.Getenv(EnvCacheTTL) != "" {
+		if config.Cache == nil {
+			config.Cache = &CacheConfig{}
+		}
+		if err := envDuration(EnvCacheTTL, &config.Cache.TTL); err != nil {
+			return config, err
+		}
+	}
+
+	return config, nil
+}
+
+// ClientOptions converts the configuration to client options. An API key
+// replaces any key provider set in code. The cache is created here, so that
+// a FileCache directory that cannot be created is reported early.
+//
+// Returns:
+// - []ClientOptions: the options, to be passed to NewClient.
+// - error: an error wrapping ErrInvalidConfig if the cache cannot be created.
+func (config Config) ClientOptions() ([]ClientOptions, error) {
+	options := []ClientOptions{}
+
+	if config.APIKey != "" {
+		options = append(options, WithKeyProvider(StaticKey(config.APIKey)))
+	}
+
+	if config.BaseURL != "" {
+		options = append(options, WithBaseURL(config.BaseURL))
+	}
+
+	if config.Timeout > 0 {
+		options = append(options, WithTimeout(time.Duration(config.Timeout)))
+	}
+
+	if config.Search != nil {
+		options = append(options, WithRequestOptions(config.Search))
+	}
+
+	if config.Retry != nil {
+		options = append(options, withRetryConfig(*config.Retry))
+	}
+
+	if config.Cache != nil {
+		cache, err := config.Cache.cache()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+		}
+		options = append(options, WithCache(cache))
+	}
+
+	return options, nil
+}
+
+// NewClientFromConfig creates a Client from config. The options given in code
+// are applied first, so that the configuration overrides them.
+//
+// Parameters:
+// - config: the configuration.
+// - options: the options given in code, used when the configuration leaves them unset.
+//
+// Returns:
+// - *Client: the client.
+// - error: an error if the configuration is invalid or has no API key.
+func NewClientFromConfig(config Config, options ...ClientOptions) (*Client, error) {
+	configOptions, err := config.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(config.APIKey, append(append([]ClientOptions(nil), options...), configOptions...)...)
+}
+
+// NewClientFromEnv creates a Client configured from the environment, see
+// ConfigFromEnv. Settings are taken from the environment variables first,
+// then from the METAPHOR_CONFIG file, then from the options given in code,
+// and finally from the library defaults.
+//
+// Parameters:
+// - options: the options given in code, used when the environment leaves them unset.
+//
+// Returns:
+// - *Client: the client.
+// - error: an error if the configuration is invalid or has no API key.
+func NewClientFromEnv(options ...ClientOptions) (*Client, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClientFromConfig(config, options...)
+}
+
+// withRetryConfig applies the fields set in retry to the retry policy of the
+// call, or to DefaultRetryPolicy when no policy was given.
+func withRetryConfig(retry RetryConfig) ClientOptions {
+	if retry.RetryableStatusCodes != nil {
+		retry.RetryableStatusCodes = append([]int{}, retry.RetryableStatusCodes...)
+	}
+
+	return func(config *requestConfig) {
+		config.retryPolicy = retry.apply(config.retryPolicy)
+	}
+}
+
+// apply returns policy with the fields set in the configuration replaced.
+func (retry RetryConfig) apply(policy RetryPolicy) RetryPolicy {
+	if policy.MaxAttempts == 0 && policy.RetryableStatusCodes == nil && !policy.RetryNetworkErrors {
+		policy = DefaultRetryPolicy()
+	}
+
+	if retry.MaxAttempts != 0 {
+		policy.MaxAttempts = retry.MaxAttempts
+	}
+
+	if retry.BaseDelay != 0 {
+		policy.BaseDelay = time.Duration(retry.BaseDelay)
+	}
+
+	if retry.MaxDelay != 0 {
+		policy.MaxDelay = time.Duration(retry.MaxDelay)
+	}
+
+	if retry.Jitter != nil {
+		policy.Jitter = *retry.Jitter
+	}
+
+	if retry.RetryableStatusCodes != nil {
+		policy.RetryableStatusCodes = retry.RetryableStatusCodes
+	}
+
+	if retry.RetryNetworkErrors != nil {
+		policy.RetryNetworkErrors = *retry.RetryNetworkErrors
+	}
+
+	return policy
+}
+
+// cache creates the cache of the configuration.
+func (cache *CacheConfig) cache() (Cache, error) {
+	if cache.Dir != "" {
+		return NewFileCache(cache.Dir, time.Duration(cache.TTL))
+	}
+
+	maxEntries := cache.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+
+	return NewMemoryCache(maxEntries, time.Duration(cache.TTL)), nil
+}
+
+func withSearchConfig(search *RequestOptions) *RequestOptions {
+	if search == nil {
+		return &RequestOptions{}
+	}
+
+	return search
+}
+
+func envInt(name string, value string) (int, error) {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, name, err)
+	}
+
+	return parsed, nil
+}
+
+func envDuration(name string, duration *Duration) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		*duration = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidConfig, name, err)
+	}
+
+	*duration = Duration(parsed)
+
+	return nil
+}
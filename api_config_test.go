@@ -0,0 +1,174 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+// clearEnv unsets the METAPHOR_ environment variables for the test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	for _, name := range []string{
+		metaphor.EnvAPIKey, metaphor.EnvBaseURL, metaphor.EnvConfig, metaphor.EnvTimeout, metaphor.EnvNumResults,
+		metaphor.EnvSearchType, metaphor.EnvMaxAttempts, metaphor.EnvCacheDir, metaphor.EnvCacheTTL,
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "metaphor.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	clearEnv(t)
+	server := newTestServer(t, 20)
+	t.Setenv(metaphor.EnvAPIKey, "env-key")
+
+	numResults := func() int {
+		t.Helper()
+
+		client, err := metaphor.NewClientFromEnv(metaphor.WithBaseURL(server.URL), metaphor.WithNumResults(5))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Search(context.Background(), "golang"); err != nil {
+			t.Fatal(err)
+		}
+
+		request, _ := server.LastRequest()
+		if key := request.Header.Get("x-api-key"); key != "env-key" {
+			t.Fatalf("sent key %q", key)
+		}
+
+		return request.Body.NumResults
+	}
+
+	if got := numResults(); got != 5 {
+		t.Fatalf("got %d results without configuration, want the 5 given in code", got)
+	}
+
+	t.Setenv(metaphor.EnvConfig, writeConfig(t, `{"timeout": 30, "search": {"numResults": 7}}`))
+	if got := numResults(); got != 7 {
+		t.Fatalf("got %d results, want the 7 of the file", got)
+	}
+
+	t.Setenv(metaphor.EnvNumResults, "9")
+	if got := numResults(); got != 9 {
+		t.Fatalf("got %d results, want the 9 of the environment", got)
+	}
+}
+
+func TestConfigRetryKeepsCodePolicy(t *testing.T) {
+	clearEnv(t)
+	server := newTestServer(t, 3)
+	t.Setenv(metaphor.EnvAPIKey, "env-key")
+	t.Setenv(metaphor.EnvMaxAttempts, "3")
+
+	policy := fastRetryPolicy(10)
+	policy.RetryableStatusCodes = []int{http.StatusTeapot}
+
+	client, err := metaphor.NewClientFromEnv(metaphor.WithBaseURL(server.URL), metaphor.WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(5, metaphortest.StatusFailure(http.StatusTeapot))
+	if _, err := client.Search(context.Background(), "golang"); err == nil {
+		t.Fatal("expected the search to fail")
+	}
+
+	if count := server.RequestCount(metaphor.DefaultSearchPath); count != 3 {
+		t.Fatalf("got %d attempts, want the 3 of the environment", count)
+	}
+}
+
+func TestConfigRetryDefaultsWithoutCodePolicy(t *testing.T) {
+	clearEnv(t)
+	server := newTestServer(t, 3)
+	t.Setenv(metaphor.EnvAPIKey, "env-key")
+	t.Setenv(metaphor.EnvConfig, writeConfig(t, `{"retry": {"maxAttempts": 2, "baseDelay": "1ms"}}`))
+
+	client, err := metaphor.NewClientFromEnv(metaphor.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The configuration fills in DefaultRetryPolicy, which retries 503s.
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusServiceUnavailable))
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := server.RequestCount(metaphor.DefaultSearchPath); count != 2 {
+		t.Fatalf("got %d attempts, want 2", count)
+	}
+}
+
+func TestConfigFromEnvDurations(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "30", want: 30 * time.Second},
+		{value: "1.5", want: 1500 * time.Millisecond},
+		{value: "2m", want: 2 * time.Minute},
+	}
+
+	for _, test := range tests {
+		clearEnv(t)
+		t.Setenv(metaphor.EnvTimeout, test.value)
+		t.Setenv(metaphor.EnvCacheTTL, test.value)
+
+		config, err := metaphor.ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("%s: %v", test.value, err)
+		}
+
+		if time.Duration(config.Timeout) != test.want || time.Duration(config.Cache.TTL) != test.want {
+			t.Fatalf("%s: got %v and %v, want %v", test.value, time.Duration(config.Timeout), time.Duration(config.Cache.TTL), test.want)
+		}
+	}
+
+	clearEnv(t)
+	t.Setenv(metaphor.EnvTimeout, "soon")
+	if _, err := metaphor.ConfigFromEnv(); !errors.Is(err, metaphor.ErrInvalidConfig) {
+		t.Fatalf("got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	if _, err := metaphor.LoadConfig(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, metaphor.ErrInvalidConfig) {
+		t.Fatalf("got %v, want ErrInvalidConfig", err)
+	}
+
+	path := writeConfig(t, `{"retry": {"maxAttempts": 3, "backoff": "1s"}}`)
+	if _, err := metaphor.LoadConfig(path); !errors.Is(err, metaphor.ErrInvalidConfig) {
+		t.Fatalf("got %v, want ErrInvalidConfig for an unknown field", err)
+	}
+}
+
+func TestNewClientFromEnvWithoutKey(t *testing.T) {
+	clearEnv(t)
+
+	if _, err := metaphor.NewClientFromEnv(); !errors.Is(err, metaphor.ErrMissingApiKey) {
+		t.Fatalf("got %v, want ErrMissingApiKey", err)
+	}
+}
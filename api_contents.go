@@ -0,0 +1,279 @@
+package metaphor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// ContentsRequestBody is the JSON body sent to the contents endpoint when
+// the contents method is POST, see WithContentsMethod, or when contents
+// options are set.
+type ContentsRequestBody struct {
+	IDs []string `json:"ids"`
+
+	*ContentsOptions
+}
+
+// rawContents is used to split a contents response into one JSON document
+// per ID, so that documents can be stored and merged without decoding them.
+type rawContents struct {
+	Contents []json.RawMessage `json:"contents"`
+}
+
+// ContentsBatchError is returned by GetContents when the IDs were split into
+// several batches and some of them failed. The contents of the successful
+// batches are still returned alongside the error.
+type ContentsBatchError struct {
+	// Batches is the total number of batches sent.
+	Batches int
+
+	// Failures lists the failed batches in input order.
+	Failures []ContentsBatchFailure
+}
+
+// ContentsBatchFailure describes a failed GetContents batch.
+type ContentsBatchFailure struct {
+	// Index is the position of the batch, starting at 0.
+	Index int
+
+	// IDs holds the IDs requested by the batch.
+	IDs []string
+
+	// Err is the error returned for the batch.
+	Err error
+}
+
+// Error implements the error interface.
+func (batchErr *ContentsBatchError) Error() string {
+	return fmt.Sprintf("%d of %d contents batches failed, first error: %s",
+		len(batchErr.Failures), batchErr.Batches, batchErr.Failures[0].Err)
+}
+
+// Unwrap returns the errors of the failed batches, so that errors.Is and
+// errors.As can inspect them.
+func (batchErr *ContentsBatchError) Unwrap() []error {
+	errs := make([]error, 0, len(batchErr.Failures))
+	for _, failure := range batchErr.Failures {
+		errs = append(errs, failure.Err)
+	}
+
+	return errs
+}
+
+// FailedIDs returns the IDs of all the failed batches.
+func (batchErr *ContentsBatchError) FailedIDs() []string {
+	ids := []string{}
+	for _, failure := range batchErr.Failures {
+		ids = append(ids, failure.IDs...)
+	}
+
+	return ids
+}
+
+// getContents returns the raw contents response for ids. Documents found in
+// the client content store for the same contents options are served
+// locally, only the missing IDs are requested from the API, and the
+// documents are merged back in the order of ids. When fetching the missing
+// IDs fails, the stored documents and those of the successful batches are
+// returned alongside the error.
+func (client *Client) getContents(ctx context.Context, config *requestConfig, ids []string) ([]byte, error) {
+	useStore := client.store != nil && config.cacheMode != cacheBypass
+
+	documents := make(map[string]json.RawMessage, len(ids))
+	missing := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := documents[id]; ok {
+			continue
+		}
+
+		if useStore && config.cacheMode != cacheRefresh {
+			if document, ok := client.store.Get(ctx, documentCacheKey(config.baseURL, id, config.body.Contents)); ok {
+				client.cacheStats.documentHits.Add(1)
+				documents[id] = document
+				continue
+			}
+			client.cacheStats.documentMisses.Add(1)
+		}
+
+		documents[id] = nil
+		missing = append(missing, id)
+	}
+
+	var extra []json.RawMessage
+	var fetchErr error
+	if len(missing) > 0 {
+		var fetched []json.RawMessage
+		fetched, fetchErr = client.fetchContents(ctx, config, missing)
+		if fetched == nil && fetchErr != nil && len(missing) == len(documents) {
+			return nil, fetchErr
+		}
+
+		for _, document := range fetched {
+			var header struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(document, &header); err != nil {
+				return nil, err
+			}
+
+			if stored, requested := documents[header.ID]; !requested || stored != nil {
+				extra = append(extra, document)
+				continue
+			}
+
+			documents[header.ID] = document
+			if useStore {
+				client.store.Set(ctx, documentCacheKey(config.baseURL, header.ID, config.body.Contents), document)
+			}
+		}
+	}
+
+	merged := &rawContents{Contents: make([]json.RawMessage, 0, len(ids))}
+	for _, id := range ids {
+		if document := documents[id]; document != nil {
+			merged.Contents = append(merged.Contents, document)
+		}
+	}
+	merged.Contents = append(merged.Contents, extra...)
+
+	responseBody, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseBody, fetchErr
+}
+
+// fetchContents requests the documents of ids from the API. The IDs are split
+// into batches of the configured size, fetched concurrently by a bounded
+// number of workers and returned in batch order. Failed batches are reported
+// with a *ContentsBatchError, and documents are only returned when at least
+// one batch succeeded. A single batch returns its error as is.
+func (client *Client) fetchContents(ctx context.Context, config *requestConfig, ids []string) ([]json.RawMessage, error) {
+	batchSize := config.contentsBatchSize
+	if batchSize <= 0 {
+		batchSize = len(ids)
+	}
+
+	batches := [][]string{}
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batches = append(batches, ids[start:end])
+	}
+
+	if len(batches) == 1 {
+		return client.fetchContentsBatch(ctx, config, batches[0])
+	}
+
+	workers := config.contentsConcurrency
+	if workers <= 0 || workers > len(batches) {
+		workers = len(batches)
+	}
+
+	results := make([][]json.RawMessage, len(batches))
+	errs := make([]error, len(batches))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[index] = err
+					continue
+				}
+				results[index], errs[index] = client.fetchContentsBatch(ctx, config, batches[index])
+			}
+		}()
+	}
+
+	for index := range batches {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	documents := []json.RawMessage{}
+	batchErr := &ContentsBatchError{Batches: len(batches)}
+	for index, batch := range batches {
+		if errs[index] != nil {
+			batchErr.Failures = append(batchErr.Failures, ContentsBatchFailure{
+				Index: index,
+				IDs:   batch,
+				Err:   errs[index],
+			})
+			continue
+		}
+		documents = append(documents, results[index]...)
+	}
+
+	switch len(batchErr.Failures) {
+	case 0:
+		return documents, nil
+	case len(batches):
+		return nil, batchErr
+	default:
+		return documents, batchErr
+	}
+}
+
+// fetchContentsBatch requests the documents of a single batch of IDs, either
+// as URL encoded query parameters or as a JSON body depending on the
+// configured contents method. Contents options can only be sent in a JSON
+// body, so setting them always uses http.MethodPost.
+func (client *Client) fetchContentsBatch(ctx context.Context, config *requestConfig, ids []string) ([]json.RawMessage, error) {
+	reqURL := config.baseURL + DefaultContentsPath
+
+	method := config.contentsMethod
+	if config.body.Contents != nil && method == http.MethodGet {
+		method = http.MethodPost
+	}
+
+	var req *http.Request
+	switch method {
+	case http.MethodGet:
+		query := url.Values{"ids": ids}
+		var err error
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, reqURL+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+	case http.MethodPost:
+		reqBytes, err := json.Marshal(ContentsRequestBody{IDs: ids, ContentsOptions: config.body.Contents})
+		if err != nil {
+			return nil, err
+		}
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBytes))
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentsMethod, config.contentsMethod)
+	}
+
+	cacheKey := contentsCacheKey(config.baseURL, DefaultContentsPath, ids, config.body.Contents)
+	responseBody, err := client.runCachedRequest(config, req, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+
+	fetched := &rawContents{}
+	if err := json.Unmarshal(responseBody, fetched); err != nil {
+		return nil, err
+	}
+
+	return fetched.Contents, nil
+}
@@ -0,0 +1,95 @@
+package metaphor
+
+import "fmt"
+
+// ContentsOptions selects the contents returned for each document, either
+// inline with search results or by GetContents. When no option is set, the
+// contents endpoint returns the extract of each document.
+type ContentsOptions struct {
+	// Text asks for the text of every document.
+	Text *TextOptions `json:"text,omitempty"`
+
+	// Highlights asks for the sentences of every document most relevant to
+	// a query.
+	Highlights *HighlightsOptions `json:"highlights,omitempty"`
+
+	// Summary asks for a summary of every document.
+	Summary *SummaryOptions `json:"summary,omitempty"`
+}
+
+// TextOptions configures the text returned for each document.
+type TextOptions struct {
+	// MaxCharacters limits the length of the text, zero means no limit.
+	MaxCharacters int `json:"maxCharacters,omitempty"`
+
+	// IncludeHTMLTags keeps the HTML tags of the document in the text.
+	IncludeHTMLTags bool `json:"includeHtmlTags,omitempty"`
+}
+
+// HighlightsOptions configures the highlights returned for each document.
+type HighlightsOptions struct {
+	// NumSentences is the number of sentences of each highlight.
+	NumSentences int `json:"numSentences,omitempty"`
+
+	// HighlightsPerURL is the number of highlights returned per document.
+	HighlightsPerURL int `json:"highlightsPerUrl,omitempty"`
+
+	// Query selects the highlights. It defaults to the search query.
+	Query string `json:"query,omitempty"`
+}
+
+// SummaryOptions configures the summary returned for each document.
+type SummaryOptions struct {
+	// Query focuses the summary on a question or topic.
+	Query string `json:"query,omitempty"`
+}
+
+// validateContentsOptions records the problems of options, whose fields are
+// reported under prefix.
+func validateContentsOptions(validationErr *ValidationError, prefix string, options *ContentsOptions) {
+	if options == nil {
+		return
+	}
+
+	if options.Text != nil && options.Text.MaxCharacters < 0 {
+		validationErr.add(prefix+"text.maxCharacters", fmt.Errorf("must not be negative, got %d", options.Text.MaxCharacters))
+	}
+
+	if options.Highlights != nil {
+		if options.Highlights.NumSentences < 0 {
+			validationErr.add(prefix+"highlights.numSentences", fmt.Errorf("must not be negative, got %d", options.Highlights.NumSentences))
+		}
+
+		if options.Highlights.HighlightsPerURL < 0 {
+			validationErr.add(prefix+"highlights.highlightsPerUrl", fmt.Errorf("must not be negative, got %d", options.Highlights.HighlightsPerURL))
+		}
+	}
+}
+
+// hasContents reports whether the API returned any contents for result.
+func (result Result) hasContents() bool {
+	return result.Extract != "" || result.Text != "" || len(result.Highlights) > 0 || result.Summary != ""
+}
+
+// withContents returns result completed with the contents of content it
+// does not hold yet.
+func (result Result) withContents(content Content) Result {
+	if result.Extract == "" {
+		result.Extract = content.Extract
+	}
+
+	if result.Text == "" {
+		result.Text = content.Text
+	}
+
+	if len(result.Highlights) == 0 {
+		result.Highlights = content.Highlights
+		result.HighlightScores = content.HighlightScores
+	}
+
+	if result.Summary == "" {
+		result.Summary = content.Summary
+	}
+
+	return result
+}
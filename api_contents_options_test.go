@@ -0,0 +1,160 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+)
+
+func TestContentsOptionsSwitchToPost(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetContents(ctx, []string{"id-0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := server.LastRequest()
+	if request.Method != http.MethodGet || request.Contents != nil {
+		t.Fatalf("sent %s with options %+v, want a GET without options", request.Method, request.Contents)
+	}
+
+	contents, err := client.GetContents(ctx, []string{"id-0", "id-1"},
+		metaphor.WithText(metaphor.TextOptions{MaxCharacters: 6}),
+		metaphor.WithHighlights(metaphor.HighlightsOptions{NumSentences: 1, Query: "concurrency"}),
+		metaphor.WithSummary(metaphor.SummaryOptions{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ = server.LastRequest()
+	if request.Method != http.MethodPost || !reflect.DeepEqual(request.IDs, []string{"id-0", "id-1"}) {
+		t.Fatalf("sent %s for %v, want a POST with the IDs in the body", request.Method, request.IDs)
+	}
+
+	want := &metaphor.ContentsOptions{
+		Text:       &metaphor.TextOptions{MaxCharacters: 6},
+		Highlights: &metaphor.HighlightsOptions{NumSentences: 1, Query: "concurrency"},
+		Summary:    &metaphor.SummaryOptions{},
+	}
+	if !reflect.DeepEqual(request.Contents, want) {
+		t.Fatalf("sent options %+v, want %+v", request.Contents, want)
+	}
+
+	for _, content := range contents.Contents {
+		if content.Text != "Golang" || len(content.Highlights) != 1 || content.Summary == "" {
+			t.Fatalf("%s holds %+v, want the requested contents", content.ID, content)
+		}
+	}
+}
+
+func TestContentsOptionsAreCacheKeys(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithCache(metaphor.NewMemoryCache(100, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ids := []string{"id-0", "id-1"}
+	calls := [][]metaphor.ClientOptions{
+		nil,
+		{metaphor.WithText(metaphor.TextOptions{})},
+		{metaphor.WithText(metaphor.TextOptions{MaxCharacters: 10})},
+		{metaphor.WithText(metaphor.TextOptions{MaxCharacters: 10})},
+		{metaphor.WithSummary(metaphor.SummaryOptions{Query: "concurrency"})},
+		nil,
+	}
+
+	for _, options := range calls {
+		if _, err := client.GetContents(ctx, ids, options...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := server.RequestCount(metaphor.DefaultContentsPath); count != 4 {
+		t.Fatalf("got %d requests, want one per distinct set of options", count)
+	}
+}
+
+func TestContentsOptionsAreContentStoreKeys(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithContentStore(metaphor.NewMemoryCache(100, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetContents(ctx, []string{"id-0", "id-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := metaphor.WithSummary(metaphor.SummaryOptions{})
+	contents, err := client.GetContents(ctx, []string{"id-0", "id-1"}, summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := server.LastRequest()
+	if request.Contents == nil || !reflect.DeepEqual(request.IDs, []string{"id-0", "id-1"}) {
+		t.Fatalf("requested %v with options %+v, want both documents with a summary", request.IDs, request.Contents)
+	}
+
+	for _, content := range contents.Contents {
+		if content.Summary == "" {
+			t.Fatalf("%s was served from the store without a summary", content.ID)
+		}
+	}
+
+	if _, err := client.GetContents(ctx, []string{"id-1", "id-2"}, summary); err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ = server.LastRequest()
+	if !reflect.DeepEqual(request.IDs, []string{"id-2"}) {
+		t.Fatalf("requested %v, want only the document without a stored summary", request.IDs)
+	}
+}
+
+func TestContentsOptionsValidation(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	text := metaphor.WithText(metaphor.TextOptions{MaxCharacters: -1})
+	highlights := metaphor.WithHighlights(metaphor.HighlightsOptions{NumSentences: -2})
+
+	_, err = client.GetContents(ctx, []string{"id-0"}, text, highlights)
+	if !errors.Is(err, metaphor.ErrInvalidRequest) {
+		t.Fatalf("got %v, want ErrInvalidRequest", err)
+	}
+
+	if got := problemFields(err); !reflect.DeepEqual(got, []string{"text.maxCharacters", "highlights.numSentences"}) {
+		t.Fatalf("got problems %v", got)
+	}
+
+	_, err = client.SearchAndContents(ctx, "golang", text, highlights)
+	if !errors.Is(err, metaphor.ErrInvalidRequest) {
+		t.Fatalf("got %v, want ErrInvalidRequest", err)
+	}
+
+	if got := problemFields(err); !reflect.DeepEqual(got, []string{"contents.text.maxCharacters", "contents.highlights.numSentences"}) {
+		t.Fatalf("got problems %v", got)
+	}
+
+	if count := server.RequestCount(""); count != 0 {
+		t.Fatalf("%d invalid requests were sent", count)
+	}
+}
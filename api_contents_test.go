@@ -0,0 +1,207 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+func contentIDs(contents []metaphor.Content) []string {
+	ids := make([]string, 0, len(contents))
+	for _, content := range contents {
+		ids = append(ids, content.ID)
+	}
+
+	return ids
+}
+
+func TestContentStoreFetchesMissingIDs(t *testing.T) {
+	server := newTestServer(t, 6)
+	client, err := server.NewClient(metaphor.WithContentStore(metaphor.NewMemoryCache(100, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetContents(ctx, []string{"id-0", "id-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := client.GetContents(ctx, []string{"id-2", "id-1", "id-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := contentIDs(contents.Contents); !reflect.DeepEqual(got, []string{"id-2", "id-1", "id-0"}) {
+		t.Fatalf("got %v, want the requested order", got)
+	}
+
+	last, _ := server.LastRequest()
+	if !reflect.DeepEqual(last.IDs, []string{"id-2"}) {
+		t.Fatalf("requested %v, want only the missing id-2", last.IDs)
+	}
+
+	if stats := client.CacheStats(); stats.DocumentHits != 2 || stats.DocumentMisses != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestContentStoreKeepsStoredDocumentsOnError(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithContentStore(metaphor.NewMemoryCache(100, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetContents(ctx, []string{"id-0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusInternalServerError))
+	contents, err := client.GetContents(ctx, []string{"id-0", "id-1"})
+
+	var apiErr *metaphor.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, metaphor.ErrGetContentsFailed) {
+		t.Fatalf("got %v, want the API error", err)
+	}
+
+	if got := contentIDs(contents.Contents); !reflect.DeepEqual(got, []string{"id-0"}) {
+		t.Fatalf("got %v, want the stored id-0", got)
+	}
+}
+
+func TestGetContentsSplitsBatches(t *testing.T) {
+	server := newTestServer(t, 10)
+	client, err := server.NewClient(metaphor.WithContentsBatchSize(3), metaphor.WithContentsConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{"id-9", "id-1", "id-8", "id-2", "id-7", "id-3", "id-6", "id-4"}
+	contents, err := client.GetContents(context.Background(), ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := contentIDs(contents.Contents); !reflect.DeepEqual(got, ids) {
+		t.Fatalf("got %v, want %v", got, ids)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(requests))
+	}
+
+	for _, request := range requests {
+		if len(request.IDs) > 3 {
+			t.Fatalf("a batch holds %d IDs, want at most 3", len(request.IDs))
+		}
+	}
+}
+
+func TestGetContentsReturnsPartialBatches(t *testing.T) {
+	server := newTestServer(t, 10)
+	client, err := server.NewClient(metaphor.WithContentsBatchSize(2), metaphor.WithContentsConcurrency(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusBadGateway))
+	contents, err := client.GetContents(context.Background(), []string{"id-0", "id-1", "id-2", "id-3", "id-4"})
+
+	var batchErr *metaphor.ContentsBatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("got %v, want a ContentsBatchError", err)
+	}
+
+	if batchErr.Batches != 3 || len(batchErr.Failures) != 1 || batchErr.Failures[0].Index != 0 {
+		t.Fatalf("unexpected batch error %+v", batchErr)
+	}
+
+	if got := batchErr.FailedIDs(); !reflect.DeepEqual(got, []string{"id-0", "id-1"}) {
+		t.Fatalf("got failed IDs %v", got)
+	}
+
+	var apiErr *metaphor.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("%v does not wrap the API error", err)
+	}
+
+	if got := contentIDs(contents.Contents); !reflect.DeepEqual(got, []string{"id-2", "id-3", "id-4"}) {
+		t.Fatalf("got %v, want the contents of the other batches", got)
+	}
+}
+
+func TestGetContentsAllBatchesFail(t *testing.T) {
+	server := newTestServer(t, 10)
+	client, err := server.NewClient(metaphor.WithContentsBatchSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(2, metaphortest.StatusFailure(http.StatusBadGateway))
+	contents, err := client.GetContents(context.Background(), []string{"id-0", "id-1", "id-2"})
+
+	var batchErr *metaphor.ContentsBatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Failures) != 2 {
+		t.Fatalf("got %v, want both batches to fail", err)
+	}
+
+	if len(contents.Contents) != 0 {
+		t.Fatalf("got %d contents, want none", len(contents.Contents))
+	}
+}
+
+// FuzzGetContentsIDs checks that arbitrary IDs reach the API unchanged, both
+// as GET query parameters and in a POST body.
+func FuzzGetContentsIDs(f *testing.F) {
+	for _, id := range []string{`a"b`, "c&d=e", "f#g", "héllo", "x y+z", "%2F", "ids=1", "a,b", "?", "\\n"} {
+		f.Add(id, "id-0")
+	}
+
+	server := metaphortest.NewServer(metaphortest.Document{ID: "id-0", URL: "https://alpha.com/0", Title: "Golang"})
+	f.Cleanup(server.Close)
+
+	clients := map[string]*metaphor.Client{}
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		client, err := server.NewClient(metaphor.WithContentsMethod(method))
+		if err != nil {
+			f.Fatal(err)
+		}
+		clients[method] = client
+	}
+
+	f.Fuzz(func(t *testing.T, first string, second string) {
+		// Empty IDs are rejected before reaching the API, duplicates are only
+		// requested once, and JSON replaces invalid UTF-8.
+		if strings.TrimSpace(first) == "" || strings.TrimSpace(second) == "" || first == second ||
+			!utf8.ValidString(first) || !utf8.ValidString(second) {
+			t.Skip()
+		}
+
+		ids := []string{first, second}
+		for method, client := range clients {
+			server.Reset()
+			if _, err := client.GetContents(context.Background(), ids); err != nil && !errors.Is(err, metaphor.ErrNoSearchResults) {
+				t.Fatal(err)
+			}
+
+			request, ok := server.LastRequest()
+			if !ok {
+				t.Fatalf("%s: no request was sent", method)
+			}
+
+			if request.Method != method || !reflect.DeepEqual(request.IDs, ids) {
+				t.Fatalf("%s sent %q, want %q", request.Method, request.IDs, ids)
+			}
+		}
+	})
+}
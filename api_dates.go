@@ -0,0 +1,36 @@
+package metaphor
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateFormat is the ISO 8601 layout used to send dates to the API.
+const DateFormat = "2006-01-02T15:04:05.000Z"
+
+// formatDate formats t in UTC with DateFormat.
+func formatDate(t time.Time) string {
+	return t.UTC().Format(DateFormat)
+}
+
+// dateLayouts are the layouts accepted for dates, from the most to the least
+// precise.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, ErrMissingDate
+	}
+
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidDate, value)
+}
@@ -0,0 +1,94 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+)
+
+func TestTimeOptionsFormatDates(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paris := time.FixedZone("CET", 3600)
+	start := time.Date(2023, 3, 1, 12, 30, 0, 0, paris)
+	end := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err = client.Search(context.Background(), "golang",
+		metaphor.WithStartCrawlTime(start),
+		metaphor.WithEndCrawlTime(end),
+		metaphor.PublishedBetween(start, end),
+	)
+	if err != nil && !errors.Is(err, metaphor.ErrNoSearchResults) {
+		t.Fatal(err)
+	}
+
+	request, _ := server.LastRequest()
+	body := request.Body
+	if body.StartCrawlDate != "2023-03-01T11:30:00.000Z" || body.StartPublishedDate != body.StartCrawlDate {
+		t.Fatalf("got start dates %q and %q, want the time in UTC", body.StartCrawlDate, body.StartPublishedDate)
+	}
+
+	if body.EndCrawlDate != end.Format(metaphor.DateFormat) || body.EndPublishedDate != body.EndCrawlDate {
+		t.Fatalf("got end dates %q and %q", body.EndCrawlDate, body.EndPublishedDate)
+	}
+}
+
+func TestWithinOptionsStartFromNow(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.PublishedWithin(24*time.Hour), metaphor.CrawledWithin(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+	_, err = client.Search(context.Background(), "golang")
+	if err != nil && !errors.Is(err, metaphor.ErrNoSearchResults) {
+		t.Fatal(err)
+	}
+
+	request, _ := server.LastRequest()
+	for date, period := range map[string]time.Duration{
+		request.Body.StartPublishedDate: 24 * time.Hour,
+		request.Body.StartCrawlDate:     time.Hour,
+	} {
+		start, err := time.Parse(metaphor.DateFormat, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := before.Add(-period); start.Before(want.Add(-time.Second)) || start.After(want.Add(time.Second)) {
+			t.Fatalf("got start date %s, want about %s", start, want.UTC())
+		}
+	}
+}
+
+func TestBetweenOptionsRejectReversedRanges(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := a.Add(48 * time.Hour)
+	ctx := context.Background()
+
+	if _, err := client.Search(ctx, "golang", metaphor.CrawledBetween(b, a)); !errors.Is(err, metaphor.ErrInvalidDateRange) {
+		t.Fatalf("got %v, want ErrInvalidDateRange", err)
+	}
+
+	if _, err := client.Search(ctx, "golang", metaphor.PublishedBetween(b, a)); !errors.Is(err, metaphor.ErrInvalidDateRange) {
+		t.Fatalf("got %v, want ErrInvalidDateRange", err)
+	}
+
+	if count := server.RequestCount(""); count != 0 {
+		t.Fatalf("%d invalid requests were sent", count)
+	}
+}
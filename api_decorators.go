@@ -0,0 +1,250 @@
+package metaphor
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Decorator wraps an API to add behavior to all of its calls.
+type Decorator func(API) API
+
+// Decorate wraps api with decorators. The first decorator is the outermost
+// one and sees each call first.
+//
+// Parameters:
+// - api: the API to decorate, such as a *Client.
+// - decorators: the decorators to apply.
+//
+// Returns:
+// - API: the decorated API.
+func Decorate(api API, decorators ...Decorator) API {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		api = decorators[i](api)
+	}
+
+	return api
+}
+
+// Logger is the logging interface used by LoggingDecorator, it is
+// implemented by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// MetricsRecorder receives a measurement for every call made through a
+// MetricsDecorator.
+type MetricsRecorder interface {
+	// RecordCall is called once a call completes. operation is "Search",
+	// "FindSimilar" or "GetContents" and results is the number of results or
+	// contents returned.
+	RecordCall(ctx context.Context, operation string, duration time.Duration, results int, err error)
+}
+
+// CachingDecorator caches successful responses in cache, keyed by operation,
+// query, URL or IDs and options. The WithCacheBypass and WithCacheRefresh
+// options are honored.
+//
+// Parameters:
+// - cache: the cache, such as a MemoryCache or a FileCache.
+//
+// Returns:
+// - Decorator: a decorator adding the cache.
+func CachingDecorator(cache Cache) Decorator {
+	return func(next API) API {
+		return &cachingAPI{next: next, cache: cache}
+	}
+}
+
+// LoggingDecorator logs every call with its duration, result count and
+// error.
+//
+// Parameters:
+// - logger: the logger, such as a *log.Logger.
+//
+// Returns:
+// - Decorator: a decorator adding the logs.
+func LoggingDecorator(logger Logger) Decorator {
+	return func(next API) API {
+		return &observedAPI{
+			next: next,
+			observe: func(_ context.Context, operation string, argument string, duration time.Duration, results int, err error) {
+				if err != nil {
+					logger.Printf("metaphor: %s %s failed after %s: %v", operation, argument, duration, err)
+					return
+				}
+				logger.Printf("metaphor: %s %s returned %d results in %s", operation, argument, results, duration)
+			},
+		}
+	}
+}
+
+// MetricsDecorator reports every call to recorder.
+//
+// Parameters:
+// - recorder: the metrics recorder.
+//
+// Returns:
+// - Decorator: a decorator adding the metrics.
+func MetricsDecorator(recorder MetricsRecorder) Decorator {
+	return func(next API) API {
+		return &observedAPI{
+			next: next,
+			observe: func(ctx context.Context, operation string, _ string, duration time.Duration, results int, err error) {
+				recorder.RecordCall(ctx, operation, duration, results, err)
+			},
+		}
+	}
+}
+
+// observedAPI calls observe after every call of next. argument is the quoted
+// query or URL, or the JSON list of IDs.
+type observedAPI struct {
+	next    API
+	observe func(ctx context.Context, operation string, argument string, duration time.Duration, results int, err error)
+}
+
+func (api *observedAPI) Search(ctx context.Context, query string, options ...ClientOptions) (*SearchResponse, error) {
+	start := time.Now()
+	response, err := api.next.Search(ctx, query, options...)
+	api.observe(ctx, "Search", strconv.Quote(query), time.Since(start), searchResultCount(response), err)
+
+	return response, err
+}
+
+func (api *observedAPI) FindSimilar(ctx context.Context, url string, options ...ClientOptions) (*SearchResponse, error) {
+	start := time.Now()
+	response, err := api.next.FindSimilar(ctx, url, options...)
+	api.observe(ctx, "FindSimilar", strconv.Quote(url), time.Since(start), searchResultCount(response), err)
+
+	return response, err
+}
+
+func (api *observedAPI) GetContents(ctx context.Context, ids []string, options ...ClientOptions) (*ContentsResponse, error) {
+	start := time.Now()
+	response, err := api.next.GetContents(ctx, ids, options...)
+
+	count := 0
+	if response != nil {
+		count = len(response.Contents)
+	}
+	api.observe(ctx, "GetContents", joinIDs(ids), time.Since(start), count, err)
+
+	return response, err
+}
+
+// cachingAPI serves the responses of next from a cache.
+type cachingAPI struct {
+	next  API
+	cache Cache
+}
+
+func (api *cachingAPI) Search(ctx context.Context, query string, options ...ClientOptions) (*SearchResponse, error) {
+	config := decoratorConfig(RequestBody{Query: query}, options)
+	key := requestCacheKey("decorator", DefaultSearchPath, config.body)
+
+	return cached(ctx, api.cache, config, key, func() (*SearchResponse, error) {
+		return api.next.Search(ctx, query, options...)
+	})
+}
+
+func (api *cachingAPI) FindSimilar(ctx context.Context, url string, options ...ClientOptions) (*SearchResponse, error) {
+	config := decoratorConfig(RequestBody{URL: url}, options)
+	key := requestCacheKey("decorator", DefaultFindSimilarPath, config.body)
+
+	return cached(ctx, api.cache, config, key, func() (*SearchResponse, error) {
+		return api.next.FindSimilar(ctx, url, options...)
+	})
+}
+
+func (api *cachingAPI) GetContents(ctx context.Context, ids []string, options ...ClientOptions) (*ContentsResponse, error) {
+	config := decoratorConfig(RequestBody{}, options)
+	key := contentsCacheKey("decorator", DefaultContentsPath, ids, config.body.Contents)
+
+	response, err := cached(ctx, api.cache, config, key, func() (*ContentsResponse, error) {
+		return api.next.GetContents(ctx, ids, options...)
+	})
+	if response != nil {
+		// The key ignores the order of ids, a cached response may follow the
+		// order of another call.
+		response.Contents = orderContents(response.Contents, ids)
+	}
+
+	return response, err
+}
+
+// decoratorConfig applies the options of a call to a fresh configuration, so
+// that decorators can inspect them.
+func decoratorConfig(body RequestBody, options []ClientOptions) *requestConfig {
+	config := &requestConfig{body: body}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+// cached returns the response cached for key, or calls fetch and caches its
+// response when it succeeds.
+func cached[Response any](ctx context.Context, cache Cache, config *requestConfig, key string, fetch func() (*Response, error)) (*Response, error) {
+	if config.cacheMode == cacheBypass {
+		return fetch()
+	}
+
+	if config.cacheMode != cacheRefresh {
+		if value, ok := cache.Get(ctx, key); ok {
+			response := new(Response)
+			if err := json.Unmarshal(value, response); err == nil {
+				return response, nil
+			}
+		}
+	}
+
+	response, err := fetch()
+	if err != nil {
+		return response, err
+	}
+
+	if value, err := json.Marshal(response); err == nil {
+		cache.Set(ctx, key, value)
+	}
+
+	return response, nil
+}
+
+// orderContents returns contents in the order of ids, followed by the
+// contents of any other ID.
+func orderContents(contents []Content, ids []string) []Content {
+	byID := make(map[string][]Content, len(contents))
+	for _, content := range contents {
+		byID[content.ID] = append(byID[content.ID], content)
+	}
+
+	ordered := make([]Content, 0, len(contents))
+	for _, id := range ids {
+		ordered = append(ordered, byID[id]...)
+		delete(byID, id)
+	}
+
+	for _, content := range contents {
+		if _, ok := byID[content.ID]; ok {
+			ordered = append(ordered, content)
+		}
+	}
+
+	return ordered
+}
+
+func searchResultCount(response *SearchResponse) int {
+	if response == nil {
+		return 0
+	}
+
+	return len(response.Results)
+}
+
+func joinIDs(ids []string) string {
+	value, _ := json.Marshal(ids)
+	return string(value)
+}
@@ -0,0 +1,209 @@
+package metaphor_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+// tracingAPI records the name of its decorator in calls before calling next.
+type tracingAPI struct {
+	name  string
+	calls *[]string
+	next  metaphor.API
+}
+
+func tracing(name string, calls *[]string) metaphor.Decorator {
+	return func(next metaphor.API) metaphor.API {
+		return &tracingAPI{name: name, calls: calls, next: next}
+	}
+}
+
+func (api *tracingAPI) Search(ctx context.Context, query string, options ...metaphor.ClientOptions) (*metaphor.SearchResponse, error) {
+	*api.calls = append(*api.calls, api.name)
+	return api.next.Search(ctx, query, options...)
+}
+
+func (api *tracingAPI) FindSimilar(ctx context.Context, url string, options ...metaphor.ClientOptions) (*metaphor.SearchResponse, error) {
+	*api.calls = append(*api.calls, api.name)
+	return api.next.FindSimilar(ctx, url, options...)
+}
+
+func (api *tracingAPI) GetContents(ctx context.Context, ids []string, options ...metaphor.ClientOptions) (*metaphor.ContentsResponse, error) {
+	*api.calls = append(*api.calls, api.name)
+	return api.next.GetContents(ctx, ids, options...)
+}
+
+// metricsRecorder records the calls reported by a MetricsDecorator.
+type metricsRecorder struct {
+	mu    sync.Mutex
+	calls []recordedCall
+}
+
+type recordedCall struct {
+	operation string
+	results   int
+	err       error
+}
+
+func (recorder *metricsRecorder) RecordCall(ctx context.Context, operation string, duration time.Duration, results int, err error) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.calls = append(recorder.calls, recordedCall{operation: operation, results: results, err: err})
+}
+
+func newDecoratedClient(t *testing.T, server *metaphortest.Server) *metaphor.Client {
+	t.Helper()
+
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestDecorateOrder(t *testing.T) {
+	server := newTestServer(t, 3)
+	calls := []string{}
+	api := metaphor.Decorate(newDecoratedClient(t, server), tracing("outer", &calls), tracing("inner", &calls))
+
+	if _, err := api.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(calls, []string{"outer", "inner"}) {
+		t.Fatalf("decorators were called in order %v", calls)
+	}
+}
+
+func TestCachingDecorator(t *testing.T) {
+	server := newTestServer(t, 3)
+	api := metaphor.Decorate(newDecoratedClient(t, server), metaphor.CachingDecorator(metaphor.NewMemoryCache(100, 0)))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.Search(ctx, "golang"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := server.RequestCount(metaphor.DefaultSearchPath); count != 1 {
+		t.Fatalf("got %d requests, want the second search to be cached", count)
+	}
+
+	if _, err := api.Search(ctx, "golang", metaphor.WithCacheBypass()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Search(ctx, "golang", metaphor.WithCacheRefresh()); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := server.RequestCount(metaphor.DefaultSearchPath); count != 3 {
+		t.Fatalf("got %d requests, want bypass and refresh to be sent", count)
+	}
+
+	// Failed calls are not cached.
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusBadRequest))
+	if _, err := api.FindSimilar(ctx, "https://alpha.com/golang-0"); err == nil {
+		t.Fatal("expected the call to fail")
+	}
+
+	if _, err := api.FindSimilar(ctx, "https://alpha.com/golang-0"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCachingDecoratorKeepsContentsOrder(t *testing.T) {
+	server := newTestServer(t, 3)
+	api := metaphor.Decorate(newDecoratedClient(t, server), metaphor.CachingDecorator(metaphor.NewMemoryCache(100, 0)))
+	ctx := context.Background()
+
+	for _, ids := range [][]string{{"id-2", "id-0"}, {"id-0", "id-2"}} {
+		response, err := api.GetContents(ctx, ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := contentIDs(response.Contents); !reflect.DeepEqual(got, ids) {
+			t.Fatalf("got contents %v, want %v", got, ids)
+		}
+	}
+
+	if count := server.RequestCount(metaphor.DefaultContentsPath); count != 1 {
+		t.Fatalf("got %d requests, want the second call to be cached", count)
+	}
+}
+
+func TestMetricsDecorator(t *testing.T) {
+	server := newTestServer(t, 3)
+	recorder := &metricsRecorder{}
+	api := metaphor.Decorate(newDecoratedClient(t, server), metaphor.MetricsDecorator(recorder))
+	ctx := context.Background()
+
+	if _, err := api.Search(ctx, "golang", metaphor.WithNumResults(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.GetContents(ctx, []string{"id-0", "id-1", "id-2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusBadRequest))
+	_, searchErr := api.FindSimilar(ctx, "https://alpha.com/golang-0")
+
+	want := []recordedCall{
+		{operation: "Search", results: 2},
+		{operation: "GetContents", results: 3},
+		{operation: "FindSimilar", err: searchErr},
+	}
+	if !reflect.DeepEqual(recorder.calls, want) || searchErr == nil {
+		t.Fatalf("recorded %+v, want %+v", recorder.calls, want)
+	}
+}
+
+func TestLoggingDecorator(t *testing.T) {
+	server := newTestServer(t, 3)
+	output := &bytes.Buffer{}
+	api := metaphor.Decorate(newDecoratedClient(t, server), metaphor.LoggingDecorator(log.New(output, "", 0)))
+	ctx := context.Background()
+
+	if _, err := api.Search(ctx, "golang", metaphor.WithNumResults(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.GetContents(ctx, []string{"id-0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Search(ctx, "haskell"); !errors.Is(err, metaphor.ErrNoSearchResults) {
+		t.Fatalf("got %v, want ErrNoSearchResults", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d log lines, want 3:\n%s", len(lines), output)
+	}
+
+	for i, prefix := range []string{
+		`metaphor: Search "golang" returned 2 results in `,
+		`metaphor: GetContents ["id-0"] returned 1 results in `,
+		`metaphor: Search "haskell" failed after `,
+	} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d is %q, want the prefix %q", i, lines[i], prefix)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package metaphor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize is the number of bytes of the response body kept in an
+// APIError.
+const maxErrorBodySize = 1024
+
+// APIError is returned when the Metaphor API answers with a non-200 status.
+// Use errors.As to retrieve it from the errors returned by the client, or the
+// IsRateLimited, IsAuthError and IsTemporary helpers to classify it.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Endpoint is the path of the API endpoint that was called.
+	Endpoint string
+
+	// Message is the error message sent by the API, if the body was a JSON
+	// error response.
+	Message string
+
+	// RequestID is the value of the x-request-id response header, if any.
+	RequestID string
+
+	// RetryAfter is the delay requested by the Retry-After response header,
+	// or zero if the header was missing.
+	RetryAfter time.Duration
+
+	// Header holds the response headers.
+	Header http.Header
+
+	// Body holds the start of the raw response body.
+	Body string
+}
+
+// newAPIError builds an APIError from a non-200 response and its body.
+func newAPIError(res *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		RequestID:  res.Header.Get("x-request-id"),
+		RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+		Header:     res.Header,
+	}
+
+	if res.Request != nil && res.Request.URL != nil {
+		apiErr.Endpoint = res.Request.URL.Path
+	}
+
+	errorResponse := &ErrorResponse{}
+	if err := json.Unmarshal(body, errorResponse); err == nil {
+		apiErr.Message = errorResponse.Text
+	}
+
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+	}
+	apiErr.Body = string(body)
+
+	return apiErr
+}
+
+// Error implements the error interface.
+func (apiErr *APIError) Error() string {
+	message := apiErr.Message
+	if message == "" {
+		message = http.StatusText(apiErr.StatusCode)
+	}
+
+	return fmt.Sprintf("%s: %s returned status %d: %s", ErrRequestFailed, apiErr.Endpoint, apiErr.StatusCode, message)
+}
+
+// Unwrap returns ErrRequestFailed so that errors.Is(err, ErrRequestFailed)
+// keeps working for API errors.
+func (apiErr *APIError) Unwrap() error {
+	return ErrRequestFailed
+}
+
+// IsRateLimited reports whether err was caused by the API rejecting the
+// request because too many requests were sent.
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// IsAuthError reports whether err was caused by a missing, invalid or
+// unauthorized API key.
+func IsAuthError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// IsQuotaExceeded reports whether err was caused by the API key running out
+// of quota: a 402 response, or a 403 or 429 response mentioning its quota.
+func IsQuotaExceeded(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusPaymentRequired:
+		return true
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		return strings.Contains(strings.ToLower(apiErr.Message), "quota")
+	default:
+		return false
+	}
+}
+
+// IsTemporary reports whether err is likely to go away if the request is
+// sent again later: rate limiting, server errors and network timeouts.
+func IsTemporary(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
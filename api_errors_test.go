@@ -0,0 +1,120 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+func TestAPIErrorFields(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.Failure{
+		StatusCode: http.StatusTooManyRequests,
+		Header: http.Header{
+			"Retry-After":  []string{"7"},
+			"X-Request-Id": []string{"req-42"},
+		},
+		Body: `{"error": "slow down"}`,
+	})
+
+	_, err = client.Search(context.Background(), "golang")
+
+	var apiErr *metaphor.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want an APIError", err)
+	}
+
+	if apiErr.StatusCode != http.StatusTooManyRequests ||
+		apiErr.Endpoint != metaphor.DefaultSearchPath ||
+		apiErr.Message != "slow down" ||
+		apiErr.RequestID != "req-42" ||
+		apiErr.RetryAfter != 7*time.Second ||
+		apiErr.Body != `{"error": "slow down"}` ||
+		apiErr.Header.Get("Retry-After") != "7" {
+		t.Fatalf("unexpected APIError %+v", apiErr)
+	}
+
+	if !errors.Is(err, metaphor.ErrSearchFailed) || !errors.Is(err, metaphor.ErrRequestFailed) {
+		t.Fatalf("%v does not wrap ErrSearchFailed and ErrRequestFailed", err)
+	}
+
+	if !metaphor.IsRateLimited(err) || !metaphor.IsTemporary(err) || metaphor.IsAuthError(err) {
+		t.Fatalf("misclassified %v", err)
+	}
+}
+
+func TestAPIErrorTruncatesBody(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.Failure{
+		StatusCode: http.StatusInternalServerError,
+		Body:       strings.Repeat("x", 4096),
+	})
+
+	_, err = client.Search(context.Background(), "golang")
+
+	var apiErr *metaphor.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want an APIError", err)
+	}
+
+	if len(apiErr.Body) != 1024 {
+		t.Fatalf("got a body of %d bytes, want 1024", len(apiErr.Body))
+	}
+
+	if apiErr.Message != "" || !strings.Contains(apiErr.Error(), "Internal Server Error") {
+		t.Fatalf("unexpected message for a non JSON body: %q", apiErr.Error())
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		statusCode    int
+		message       string
+		rateLimited   bool
+		auth          bool
+		quotaExceeded bool
+		temporary     bool
+	}{
+		{statusCode: http.StatusUnauthorized, auth: true},
+		{statusCode: http.StatusForbidden, auth: true},
+		{statusCode: http.StatusForbidden, message: "Monthly quota exceeded", auth: true, quotaExceeded: true},
+		{statusCode: http.StatusPaymentRequired, quotaExceeded: true},
+		{statusCode: http.StatusTooManyRequests, rateLimited: true, temporary: true},
+		{statusCode: http.StatusTooManyRequests, message: "quota exceeded", rateLimited: true, quotaExceeded: true, temporary: true},
+		{statusCode: http.StatusBadRequest},
+		{statusCode: http.StatusServiceUnavailable, temporary: true},
+	}
+
+	for _, test := range tests {
+		err := error(&metaphor.APIError{StatusCode: test.statusCode, Message: test.message})
+
+		if got := metaphor.IsRateLimited(err); got != test.rateLimited {
+			t.Errorf("IsRateLimited(%d %q) = %v", test.statusCode, test.message, got)
+		}
+		if got := metaphor.IsAuthError(err); got != test.auth {
+			t.Errorf("IsAuthError(%d %q) = %v", test.statusCode, test.message, got)
+		}
+		if got := metaphor.IsQuotaExceeded(err); got != test.quotaExceeded {
+			t.Errorf("IsQuotaExceeded(%d %q) = %v", test.statusCode, test.message, got)
+		}
+		if got := metaphor.IsTemporary(err); got != test.temporary {
+			t.Errorf("IsTemporary(%d %q) = %v", test.statusCode, test.message, got)
+		}
+	}
+}
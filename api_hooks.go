@@ -0,0 +1,143 @@
+package metaphor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Call is a Search, FindSimilar or GetContents call, as seen by hooks.
+// Hooks may modify it: changes to the body rewrite the options of the call,
+// and headers are added to every HTTP request sent by the call.
+type Call struct {
+	// Operation is "Search", "FindSimilar" or "GetContents".
+	Operation string
+
+	// Endpoint is the API path of the call, such as DefaultSearchPath.
+	Endpoint string
+
+	// Body is the body of Search and FindSimilar calls, nil for GetContents.
+	Body *RequestBody
+
+	// Contents is the body of GetContents calls, nil for the others.
+	Contents *ContentsRequestBody
+
+	// Header holds the headers added to the requests of the call. The
+	// x-api-key header cannot be set by hooks.
+	Header http.Header
+}
+
+// CallResponse is the outcome of a call, as seen by AfterResponseHook.
+// Hooks may modify the responses, for instance to annotate them.
+type CallResponse struct {
+	// Search is the response of Search and FindSimilar calls.
+	Search *SearchResponse
+
+	// Contents is the response of GetContents calls.
+	Contents *ContentsResponse
+
+	// Err is the error returned by the call, if any.
+	Err error
+}
+
+// BeforeRequestHook is called before a call is validated and sent. Returning
+// an error vetoes the call, which then fails with ErrCallRejected.
+type BeforeRequestHook func(ctx context.Context, call *Call) error
+
+// AfterResponseHook is called once a call has decoded its response, or
+// failed. Returning an error makes the call fail with ErrCallRejected, the
+// response being still returned.
+type AfterResponseHook func(ctx context.Context, call *Call, response *CallResponse) error
+
+// runBeforeRequestHooks runs the hooks of the call in order, stopping at the
+// first error, and applies their changes to the headers of the call. The
+// body is copied first, so that hooks never modify the slices of the caller
+// or of the options.
+func (client *Client) runBeforeRequestHooks(ctx context.Context, config *requestConfig, call *Call) error {
+	if len(config.beforeRequestHooks) == 0 {
+		return nil
+	}
+
+	if call.Body != nil {
+		*call.Body = call.Body.clone()
+	}
+	if call.Contents != nil {
+		call.Contents.IDs = append([]string(nil), call.Contents.IDs...)
+		call.Contents.ContentsOptions = call.Contents.ContentsOptions.clone()
+	}
+	call.Header = http.Header{}
+
+	for _, hook := range config.beforeRequestHooks {
+		if err := hook(ctx, call); err != nil {
+			return fmt.Errorf("%w: %w", ErrCallRejected, err)
+		}
+	}
+
+	config.header = call.Header
+
+	return nil
+}
+
+// runAfterResponseHooks runs the hooks of the call in order, stopping at the
+// first error.
+func (client *Client) runAfterResponseHooks(ctx context.Context, config *requestConfig, call *Call, response *CallResponse) error {
+	for _, hook := range config.afterResponseHooks {
+		if err := hook(ctx, call, response); err != nil {
+			return fmt.Errorf("%w: %w", ErrCallRejected, err)
+		}
+	}
+
+	return nil
+}
+
+// clone returns a deep copy of body.
+func (body RequestBody) clone() RequestBody {
+	body.IncludeDomains = append([]string(nil), body.IncludeDomains...)
+	body.ExcludeDomains = append([]string(nil), body.ExcludeDomains...)
+	body.Contents = body.Contents.clone()
+
+	return body
+}
+
+// clone returns a deep copy of options.
+func (options *ContentsOptions) clone() *ContentsOptions {
+	if options == nil {
+		return nil
+	}
+
+	cloned := *options
+	if options.Text != nil {
+		text := *options.Text
+		cloned.Text = &text
+	}
+	if options.Highlights != nil {
+		highlights := *options.Highlights
+		cloned.Highlights = &highlights
+	}
+	if options.Summary != nil {
+		summary := *options.Summary
+		cloned.Summary = &summary
+	}
+
+	return &cloned
+}
+
+// Annotate records an annotation on the response, typically from an
+// AfterResponseHook. Annotations are not sent by the API nor cached.
+func (response *SearchResponse) Annotate(key string, value string) {
+	if response.Annotations == nil {
+		response.Annotations = map[string]string{}
+	}
+
+	response.Annotations[key] = value
+}
+
+// Annotate records an annotation on the response, typically from an
+// AfterResponseHook. Annotations are not sent by the API nor cached.
+func (response *ContentsResponse) Annotate(key string, value string) {
+	if response.Annotations == nil {
+		response.Annotations = map[string]string{}
+	}
+
+	response.Annotations[key] = value
+}
@@ -0,0 +1,159 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+func TestBeforeRequestHookVetoesCall(t *testing.T) {
+	server := newTestServer(t, 3)
+	veto := errors.New("over budget")
+	client, err := server.NewClient(metaphor.WithBeforeRequest(func(ctx context.Context, call *metaphor.Call) error {
+		return veto
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.Search(ctx, "golang"); !errors.Is(err, metaphor.ErrCallRejected) || !errors.Is(err, veto) {
+		t.Fatalf("got %v, want ErrCallRejected wrapping the hook error", err)
+	}
+
+	if _, err := client.GetContents(ctx, []string{"id-0"}); !errors.Is(err, metaphor.ErrCallRejected) {
+		t.Fatalf("got %v, want ErrCallRejected", err)
+	}
+
+	if count := server.RequestCount(""); count != 0 {
+		t.Fatalf("%d vetoed requests were sent", count)
+	}
+}
+
+func TestBeforeRequestHookRewritesBody(t *testing.T) {
+	server := newTestServer(t, 10)
+	domains := []string{"alpha.com"}
+	client, err := server.NewClient(
+		metaphor.WithIncludeDomains(domains),
+		metaphor.WithBeforeRequest(func(ctx context.Context, call *metaphor.Call) error {
+			if call.Body != nil {
+				call.Body.NumResults = 2
+				call.Body.IncludeDomains[0] = "beta.com"
+			}
+			if call.Contents != nil {
+				call.Contents.IDs = call.Contents.IDs[:1]
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.Search(ctx, "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := server.LastRequest()
+	if request.Body.NumResults != 2 || request.Body.IncludeDomains[0] != "beta.com" {
+		t.Fatalf("the hook changes were not sent: %+v", request.Body)
+	}
+
+	// The hook works on a copy of the options.
+	if domains[0] != "alpha.com" {
+		t.Fatalf("the hook modified the domains of the caller: %v", domains)
+	}
+
+	response, err := client.GetContents(ctx, []string{"id-0", "id-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Contents) != 1 || response.Contents[0].ID != "id-0" {
+		t.Fatalf("got contents %v, want the IDs rewritten by the hook", contentIDs(response.Contents))
+	}
+}
+
+func TestBeforeRequestHookCannotSetAPIKey(t *testing.T) {
+	server := newTestServer(t, 3)
+	server.ExpectAPIKey("secret")
+	client, err := server.NewClient(metaphor.WithBeforeRequest(func(ctx context.Context, call *metaphor.Call) error {
+		call.Header.Set("x-api-key", "stolen")
+		call.Header.Set("X-Request-Source", "hooks")
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := server.LastRequest()
+	if got := request.Header.Values("x-api-key"); len(got) != 1 || got[0] != "secret" {
+		t.Fatalf("sent x-api-key %v, want the key of the client", got)
+	}
+
+	if got := request.Header.Get("X-Request-Source"); got != "hooks" {
+		t.Fatalf("sent X-Request-Source %q, want the header of the hook", got)
+	}
+}
+
+func TestAfterResponseHook(t *testing.T) {
+	server := newTestServer(t, 3)
+	rejected := errors.New("unsafe result")
+	client, err := server.NewClient(metaphor.WithAfterResponse(func(ctx context.Context, call *metaphor.Call, response *metaphor.CallResponse) error {
+		if response.Err != nil {
+			return nil
+		}
+
+		if call.Operation == "GetContents" {
+			response.Contents.Annotate("reviewed", "yes")
+			return nil
+		}
+
+		response.Search.Annotate("reviewed", "yes")
+		if call.Body.Query == "unsafe" {
+			return rejected
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	search, err := client.Search(ctx, "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if search.Annotations["reviewed"] != "yes" {
+		t.Fatalf("got annotations %v", search.Annotations)
+	}
+
+	contents, err := client.GetContents(ctx, []string{"id-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if contents.Annotations["reviewed"] != "yes" {
+		t.Fatalf("got annotations %v", contents.Annotations)
+	}
+
+	// A rejected response is still returned with the error.
+	server.AddDocuments(metaphortest.Document{ID: "unsafe", URL: "https://delta.com/unsafe", Title: "unsafe"})
+	search, err = client.Search(ctx, "unsafe")
+	if !errors.Is(err, metaphor.ErrCallRejected) || !errors.Is(err, rejected) {
+		t.Fatalf("got %v, want ErrCallRejected wrapping the hook error", err)
+	}
+
+	if search == nil || len(search.Results) == 0 {
+		t.Fatal("the rejected response was not returned")
+	}
+}
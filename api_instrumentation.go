@@ -0,0 +1,132 @@
+package metaphor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Instrumentation observes the calls made by a Client and the HTTP requests
+// they send, for tracing and metrics. It is set with WithInstrumentation, see
+// the otelmetaphor module for an OpenTelemetry implementation.
+//
+// Each Start method returns a context, which is used for the rest of the
+// call or request so that spans nest, and a function called exactly once
+// when the call or request completes.
+type Instrumentation interface {
+	// StartCall is called when a Search, FindSimilar or GetContents call
+	// starts, after its options have been applied.
+	StartCall(ctx context.Context, call CallInfo) (context.Context, func(CallOutcome))
+
+	// StartRequest is called when a request is sent to the API, including
+	// its retries. Responses served from the cache send no request.
+	StartRequest(ctx context.Context, request RequestInfo) (context.Context, func(RequestOutcome))
+}
+
+// CallInfo describes a Search, FindSimilar or GetContents call.
+type CallInfo struct {
+	// Operation is "Search", "FindSimilar" or "GetContents".
+	Operation string
+
+	// Endpoint is the API path of the call, such as DefaultSearchPath.
+	Endpoint string
+
+	// SearchType is the type of search performed, empty for FindSimilar and
+	// GetContents. SearchTypeAuto is reported as the type chosen.
+	SearchType SearchType
+
+	// NumResults is the number of results requested, or the number of IDs
+	// for GetContents.
+	NumResults int
+}
+
+// CallOutcome describes a completed call.
+type CallOutcome struct {
+	// Results is the number of results or contents returned.
+	Results int
+
+	// Duration is the duration of the call.
+	Duration time.Duration
+
+	// Err is the error of the call, if any.
+	Err error
+}
+
+// RequestInfo describes a request sent to the API.
+type RequestInfo struct {
+	// Method is the HTTP method of the request.
+	Method string
+
+	// Endpoint is the API path of the request, such as DefaultSearchPath.
+	Endpoint string
+}
+
+// RequestOutcome describes a completed request.
+type RequestOutcome struct {
+	// StatusCode is the HTTP status of the last attempt, zero if no
+	// response was received.
+	StatusCode int
+
+	// Attempts is the number of attempts, including retries.
+	Attempts int
+
+	// Duration is the duration of the request, including retries.
+	Duration time.Duration
+
+	// Err is the error of the request, if any.
+	Err error
+}
+
+// startCall reports the start of a call to the client instrumentation, if
+// any, and returns the context of the call and the function ending it.
+func (client *Client) startCall(ctx context.Context, call CallInfo) (context.Context, func(results int, err error)) {
+	if client.instrumentation == nil {
+		return ctx, func(int, error) {}
+	}
+
+	start := time.Now()
+	ctx, end := client.instrumentation.StartCall(ctx, call)
+
+	return ctx, func(results int, err error) {
+		end(CallOutcome{Results: results, Duration: time.Since(start), Err: err})
+	}
+}
+
+// startRequest reports the start of a request to the client instrumentation,
+// if any, and returns the request with the context of the request and the
+// function ending it.
+func (client *Client) startRequest(req *http.Request) (*http.Request, func(attempts int, err error)) {
+	if client.instrumentation == nil {
+		return req, func(int, error) {}
+	}
+
+	start := time.Now()
+	ctx, end := client.instrumentation.StartRequest(req.Context(), RequestInfo{
+		Method:   req.Method,
+		Endpoint: req.URL.Path,
+	})
+
+	return req.WithContext(ctx), func(attempts int, err error) {
+		end(RequestOutcome{
+			StatusCode: statusCode(err),
+			Attempts:   attempts,
+			Duration:   time.Since(start),
+			Err:        err,
+		})
+	}
+}
+
+// statusCode returns the HTTP status of a request that returned err.
+func statusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+
+	return 0
+}
@@ -0,0 +1,30 @@
+package metaphor
+
+import "context"
+
+// Searcher performs searches, it is implemented by *Client.
+type Searcher interface {
+	Search(ctx context.Context, query string, options ...ClientOptions) (*SearchResponse, error)
+}
+
+// SimilarFinder finds links similar to a URL, it is implemented by *Client.
+type SimilarFinder interface {
+	FindSimilar(ctx context.Context, url string, options ...ClientOptions) (*SearchResponse, error)
+}
+
+// ContentGetter retrieves the contents of documents, it is implemented by
+// *Client.
+type ContentGetter interface {
+	GetContents(ctx context.Context, ids []string, options ...ClientOptions) (*ContentsResponse, error)
+}
+
+// API groups all the operations of the Metaphor API. It is implemented by
+// *Client and by the decorators returned by Decorate, so code depending on
+// API can be given a fake or a decorated client.
+type API interface {
+	Searcher
+	SimilarFinder
+	ContentGetter
+}
+
+var _ API = (*Client)(nil)
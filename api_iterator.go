@@ -0,0 +1,342 @@
+package metaphor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// pageStrategy is how a ResultIterator asks for the next page of results.
+type pageStrategy int
+
+const (
+	// pageByDate splits the published date range into smaller windows until
+	// each holds less than a page of results.
+	pageByDate pageStrategy = iota
+
+	// pageByDomain excludes the domains of the results seen so far.
+	pageByDomain
+
+	// pageDone means no more pages can be requested.
+	pageDone
+)
+
+// ResultIterator yields search results one at a time, transparently
+// requesting more pages when needed. The API has no pagination cursor and
+// ranks results by relevance, so a full page is followed by two pages over
+// the published dates before and after the middle date of that page, and
+// so on until every date window holds less than a page of results. Domain
+// paging then excludes the domains already seen. Results are de-duplicated
+// by ID and URL.
+//
+// Coverage is best effort. Results without a published date are only found
+// on the first page or by domain paging, as are results beyond the first
+// page of a window that cannot be split further, such as more than a page
+// of results published at the same millisecond. Domain paging cannot reach
+// the remaining results of a domain already seen. The iterator stops without
+// error once no new results can be found.
+//
+// A ResultIterator is not safe for concurrent use. Use it like a
+// bufio.Scanner:
+//
+//	iter := client.SearchIter(ctx, "Who is RDJ?", 200)
+//	for iter.Next() {
+//		result := iter.Result()
+//		// ...
+//	}
+//	if err := iter.Err(); err != nil {
+//		// ...
+//	}
+type ResultIterator struct {
+	ctx     context.Context
+	fetch   func(ctx context.Context, options ...ClientOptions) (*SearchResponse, error)
+	options []ClientOptions
+	limit   int
+
+	// startPublished, endPublished and includeDomains come from the options
+	// of the iterator and bound the pages that can be requested.
+	startPublished time.Time
+	endPublished   time.Time
+	includeDomains bool
+
+	// pageSize is the number of results requested per page. A page with
+	// fewer results means its date window is exhausted.
+	pageSize int
+
+	strategy pageStrategy
+	windows  []dateWindow
+	excluded []string
+
+	seenIDs     map[string]bool
+	seenURLs    map[string]bool
+	seenDomains []string
+	buffer      []Result
+	yielded     int
+	current     Result
+	err         error
+}
+
+// SearchIter returns an iterator over the results of query, requesting as
+// many pages as needed to yield up to limit results. The options apply to
+// every page, WithNumResults setting the page size.
+//
+// Parameters:
+// - ctx: The context.Context for the requests.
+// - query: The search query.
+// - limit: The maximum number of results to yield, zero or less means no limit.
+// - options: The optional client options.
+//
+// Returns:
+// - *ResultIterator: The result iterator.
+func (client *Client) SearchIter(ctx context.Context, query string, limit int, options ...ClientOptions) *ResultIterator {
+	fetch := func(ctx context.Context, pageOptions ...ClientOptions) (*SearchResponse, error) {
+		return client.Search(ctx, query, pageOptions...)
+	}
+
+	return client.newResultIterator(ctx, fetch, limit, options)
+}
+
+// FindSimilarIter returns an iterator over the links similar to url,
+// requesting as many pages as needed to yield up to limit results. The
+// options apply to every page, WithNumResults setting the page size.
+//
+// Parameters:
+// - ctx: The context.Context for the requests.
+// - url: The URL to search for similar items.
+// - limit: The maximum number of results to yield, zero or less means no limit.
+// - options: Optional client options.
+//
+// Returns:
+// - *ResultIterator: The result iterator.
+func (client *Client) FindSimilarIter(ctx context.Context, url string, limit int, options ...ClientOptions) *ResultIterator {
+	fetch := func(ctx context.Context, pageOptions ...ClientOptions) (*SearchResponse, error) {
+		return client.FindSimilar(ctx, url, pageOptions...)
+	}
+
+	return client.newResultIterator(ctx, fetch, limit, options)
+}
+
+func (client *Client) newResultIterator(
+	ctx context.Context,
+	fetch func(ctx context.Context, options ...ClientOptions) (*SearchResponse, error),
+	limit int,
+	options []ClientOptions,
+) *ResultIterator {
+	config := client.newRequestConfig(RequestBody{}, options)
+
+	iter := &ResultIterator{
+		ctx:            ctx,
+		fetch:          fetch,
+		options:        append([]ClientOptions(nil), options...),
+		limit:          limit,
+		includeDomains: len(config.body.IncludeDomains) > 0,
+		excluded:       append([]string(nil), config.body.ExcludeDomains...),
+		seenIDs:        map[string]bool{},
+		seenURLs:       map[string]bool{},
+	}
+
+	iter.startPublished, _ = parseDate(config.body.StartPublishedDate)
+	iter.endPublished, _ = parseDate(config.body.EndPublishedDate)
+	iter.windows = []dateWindow{{start: iter.startPublished, end: iter.endPublished}}
+
+	iter.pageSize = config.body.NumResults
+	if iter.pageSize <= 0 {
+		iter.pageSize = DefaultNumResults
+	}
+
+	return iter
+}
+
+// Next advances the iterator to the next result, which is then available
+// through Result. It returns false when the limit is reached, when no more
+// results can be found, or on error.
+func (iter *ResultIterator) Next() bool {
+	if iter.err != nil || (iter.limit > 0 && iter.yielded >= iter.limit) {
+		return false
+	}
+
+	for len(iter.buffer) == 0 {
+		if iter.strategy == pageDone {
+			return false
+		}
+
+		if err := iter.ctx.Err(); err != nil {
+			iter.err = err
+			return false
+		}
+
+		if err := iter.fetchPage(); err != nil {
+			iter.err = err
+			return false
+		}
+	}
+
+	iter.current = iter.buffer[0]
+	iter.buffer = iter.buffer[1:]
+	iter.yielded++
+
+	return true
+}
+
+// Result returns the current result.
+func (iter *ResultIterator) Result() Result {
+	return iter.current
+}
+
+// Err returns the error that stopped the iterator, if any.
+func (iter *ResultIterator) Err() error {
+	return iter.err
+}
+
+// dateWindow is a published date range, both bounds included. A zero bound
+// leaves that side of the range open.
+type dateWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// contains reports whether t is in the window.
+func (window dateWindow) contains(t time.Time) bool {
+	return (window.start.IsZero() || !t.Before(window.start)) && (window.end.IsZero() || !t.After(window.end))
+}
+
+// split splits the window around the middle of dates, the published dates of
+// a full page in the window. It returns no windows when the dates are all
+// the same millisecond, or outside the window.
+func (window dateWindow) split(dates []time.Time) []dateWindow {
+	distinct := []time.Time{}
+	for _, date := range dates {
+		date = date.Truncate(time.Millisecond)
+		if !window.contains(date) {
+			continue
+		}
+
+		found := false
+		for _, seen := range distinct {
+			found = found || seen.Equal(date)
+		}
+		if !found {
+			distinct = append(distinct, date)
+		}
+	}
+
+	if len(distinct) < 2 {
+		return nil
+	}
+
+	sort.Slice(distinct, func(i, j int) bool { return distinct[i].Before(distinct[j]) })
+	middle := distinct[len(distinct)/2]
+
+	// The newer window is last, to be requested first.
+	return []dateWindow{
+		{start: window.start, end: middle.Add(-time.Millisecond)},
+		{start: middle, end: window.end},
+	}
+}
+
+// fetchPage requests the next page and buffers its new results. It moves to
+// the next strategy when the current one cannot find new results.
+func (iter *ResultIterator) fetchPage() error {
+	options := append([]ClientOptions(nil), iter.options...)
+
+	window := dateWindow{}
+	if iter.strategy == pageByDate {
+		window = iter.windows[len(iter.windows)-1]
+		iter.windows = iter.windows[:len(iter.windows)-1]
+
+		if !window.start.IsZero() {
+			options = append(options, WithStartPublishedDate(formatDate(window.start)))
+		}
+		if !window.end.IsZero() {
+			options = append(options, WithEndPublishedDate(formatDate(window.end)))
+		}
+	}
+	if iter.strategy == pageByDomain {
+		options = append(options, WithExcludeDomains(iter.excluded))
+	}
+
+	response, err := iter.fetch(iter.ctx, options...)
+	if errors.Is(err, ErrNoSearchResults) || errors.Is(err, ErrNoLinksFound) {
+		iter.nextWindow()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	added := 0
+	dates := []time.Time{}
+	for _, result := range response.Results {
+		if published, err := result.PublishedTime(); err == nil {
+			dates = append(dates, published)
+		}
+
+		if iter.strategy == pageByDomain {
+			iter.excludeDomain(result.Domain())
+		}
+
+		if iter.seenIDs[result.ID] || (result.URL != "" && iter.seenURLs[result.URL]) {
+			continue
+		}
+		iter.seenIDs[result.ID] = true
+		iter.seenURLs[result.URL] = true
+		iter.seenDomains = append(iter.seenDomains, result.Domain())
+
+		iter.buffer = append(iter.buffer, result)
+		added++
+	}
+
+	switch iter.strategy {
+	case pageByDate:
+		if len(response.Results) >= iter.pageSize {
+			iter.windows = append(iter.windows, window.split(dates)...)
+		}
+		iter.nextWindow()
+	case pageByDomain:
+		if added == 0 {
+			iter.nextStrategy()
+		}
+	}
+
+	return nil
+}
+
+// nextWindow switches to the next strategy once every date window has been
+// requested.
+func (iter *ResultIterator) nextWindow() {
+	if iter.strategy != pageByDate || len(iter.windows) == 0 {
+		iter.nextStrategy()
+	}
+}
+
+// nextStrategy switches from date paging to domain paging, and from domain
+// paging to done. Domain paging uses the original date window and is
+// skipped when the options restrict the included domains, since both
+// domain lists cannot be set at once.
+func (iter *ResultIterator) nextStrategy() {
+	if iter.strategy != pageByDate || iter.includeDomains {
+		iter.strategy = pageDone
+		return
+	}
+
+	iter.strategy = pageByDomain
+	iter.windows = nil
+	for _, domain := range iter.seenDomains {
+		iter.excludeDomain(domain)
+	}
+}
+
+func (iter *ResultIterator) excludeDomain(domain string) {
+	if domain == "" {
+		return
+	}
+
+	for _, excluded := range iter.excluded {
+		if excluded == domain {
+			return
+		}
+	}
+
+	iter.excluded = append(iter.excluded, domain)
+}
@@ -0,0 +1,212 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+// newPagingServer starts a metaphortest.Server serving n documents about
+// golang, each on its own domain. The documents are served newest first,
+// published one day apart when dated.
+func newPagingServer(t *testing.T, n int, dated bool) *metaphortest.Server {
+	t.Helper()
+
+	documents := make([]metaphortest.Document, 0, n)
+	for i := 0; i < n; i++ {
+		document := metaphortest.Document{
+			ID:    fmt.Sprintf("doc-%d", i),
+			URL:   fmt.Sprintf("https://site%d.com/golang", i),
+			Title: fmt.Sprintf("Golang %d", i),
+		}
+		if dated {
+			document.PublishedDate = fmt.Sprintf("2023-12-%02d", 28-i)
+		}
+		documents = append(documents, document)
+	}
+
+	server := metaphortest.NewServer(documents...)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func collect(t *testing.T, iter *metaphor.ResultIterator) []metaphor.Result {
+	t.Helper()
+
+	results := []metaphor.Result{}
+	seen := map[string]bool{}
+	for iter.Next() {
+		result := iter.Result()
+		if seen[result.ID] {
+			t.Fatalf("%s was yielded twice", result.ID)
+		}
+		seen[result.ID] = true
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func TestSearchIterPagesByDate(t *testing.T) {
+	server := newPagingServer(t, 20, true)
+	client, err := server.NewClient(metaphor.WithNumResults(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iter := client.SearchIter(context.Background(), "golang", 10)
+	results := collect(t, iter)
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+
+	requests := server.Requests()
+	if len(requests) < 3 {
+		t.Fatalf("got %d requests, want several pages", len(requests))
+	}
+
+	first, second := requests[0].Body, requests[1].Body
+	if first.StartPublishedDate != "" || first.EndPublishedDate != "" || (second.StartPublishedDate == "" && second.EndPublishedDate == "") {
+		t.Fatalf("the date range was not split: %+v then %+v", first, second)
+	}
+}
+
+func TestSearchIterFindsNewerResultsRankedLower(t *testing.T) {
+	// The corpus is ranked by relevance, unrelated to the published dates:
+	// documents mentioning iterators rank first whatever their date.
+	documents := []metaphortest.Document{}
+	for i := 0; i < 30; i++ {
+		title := fmt.Sprintf("Golang %d", i)
+		if i%4 == 0 {
+			title += " iterators"
+		}
+
+		documents = append(documents, metaphortest.Document{
+			ID:            fmt.Sprintf("doc-%d", i),
+			URL:           fmt.Sprintf("https://site%d.com/golang/%d", i%3, i),
+			Title:         title,
+			PublishedDate: fmt.Sprintf("2024-01-%02d", i*11%28+1),
+		})
+	}
+
+	server := metaphortest.NewServer(documents...)
+	defer server.Close()
+
+	client, err := server.NewClient(metaphor.WithNumResults(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iter := client.SearchIter(context.Background(), "golang iterators", 0)
+	results := collect(t, iter)
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 30 {
+		t.Fatalf("got %d results, want the 30 documents", len(results))
+	}
+}
+
+func TestSearchIterPagesByDomain(t *testing.T) {
+	server := newPagingServer(t, 7, false)
+	client, err := server.NewClient(metaphor.WithNumResults(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iter := client.SearchIter(context.Background(), "golang", 0)
+	results := collect(t, iter)
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 7 {
+		t.Fatalf("got %d results, want the 7 documents", len(results))
+	}
+
+	last, _ := server.LastRequest()
+	if len(last.Body.ExcludeDomains) != 7 {
+		t.Fatalf("the last page excluded %v, want every domain", last.Body.ExcludeDomains)
+	}
+}
+
+func TestSearchIterStopsOnError(t *testing.T) {
+	server := newPagingServer(t, 20, true)
+	client, err := server.NewClient(metaphor.WithNumResults(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iter := client.SearchIter(context.Background(), "golang", 0)
+	if !iter.Next() {
+		t.Fatal(iter.Err())
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusInternalServerError))
+	results := collect(t, iter)
+
+	var apiErr *metaphor.APIError
+	if !errors.As(iter.Err(), &apiErr) {
+		t.Fatalf("got %v, want the API error", iter.Err())
+	}
+
+	if len(results) != 2 || iter.Next() {
+		t.Fatalf("got %d more results, want the rest of the first page", len(results))
+	}
+}
+
+func TestSearchIterHonorsContext(t *testing.T) {
+	server := newPagingServer(t, 20, true)
+	client, err := server.NewClient(metaphor.WithNumResults(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	iter := client.SearchIter(ctx, "golang", 0)
+	for i := 0; i < 2; i++ {
+		if !iter.Next() {
+			t.Fatal(iter.Err())
+		}
+	}
+
+	cancel()
+	if iter.Next() || !errors.Is(iter.Err(), context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", iter.Err())
+	}
+}
+
+func TestFindSimilarIter(t *testing.T) {
+	server := newPagingServer(t, 9, true)
+	client, err := server.NewClient(metaphor.WithNumResults(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iter := client.FindSimilarIter(context.Background(), "https://site0.com/golang", 0)
+	results := collect(t, iter)
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 8 {
+		t.Fatalf("got %d results, want the 8 other documents", len(results))
+	}
+
+	for _, result := range results {
+		if result.ID == "doc-0" {
+			t.Fatal("the source document was yielded")
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package metaphor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKeyCooldown is how long a KeyPool stops using a key rejected by the
+// API when no cooldown is given.
+const DefaultKeyCooldown = time.Minute
+
+// maxKeyFailovers bounds the number of times a single request fails over to
+// another key, whatever the KeyFailover implementation.
+const maxKeyFailovers = 16
+
+// KeyProvider provides the API key of every request. It is consulted before
+// each attempt, so that keys can be rotated without recreating the Client.
+// Implementations must be safe for concurrent use.
+type KeyProvider interface {
+	// Key returns the API key to use for the next request.
+	Key(ctx context.Context) (string, error)
+}
+
+// KeyFailover is implemented by key providers holding several keys. When
+// the API rejects a key with an authentication or quota error, the request
+// is sent again with the next key, without counting against the retry
+// policy, as long as Failover returns true.
+type KeyFailover interface {
+	// Failover is called when key was rejected with err. It returns whether
+	// another key is available.
+	Failover(ctx context.Context, key string, err error) bool
+}
+
+// StaticKey returns a KeyProvider always providing key. It is used by
+// NewClient for the API key it is given.
+//
+// Parameters:
+// - key: the API key.
+//
+// Returns:
+// - KeyProvider: the key provider.
+func StaticKey(key string) KeyProvider {
+	return staticKey(key)
+}
+
+type staticKey string
+
+func (key staticKey) Key(ctx context.Context) (string, error) {
+	if key == "" {
+		return "", ErrMissingApiKey
+	}
+
+	return string(key), nil
+}
+
+// EnvKey returns a KeyProvider reading the API key from the environment
+// variable name on every request.
+//
+// Parameters:
+// - name: the name of the environment variable, such as "METAPHOR_API_KEY".
+//
+// Returns:
+// - KeyProvider: the key provider.
+func EnvKey(name string) KeyProvider {
+	return envKey(name)
+}
+
+type envKey string
+
+func (name envKey) Key(ctx context.Context) (string, error) {
+	key := strings.TrimSpace(os.Getenv(string(name)))
+	if key == "" {
+		return "", fmt.Errorf("%w: environment variable %s is empty", ErrMissingApiKey, string(name))
+	}
+
+	return key, nil
+}
+
+// FileKeyProvider reads the API key from a file, and reads it again whenever
+// the file changes. Surrounding whitespace is ignored. If the file becomes
+// unreadable, for instance while it is being replaced, the last key read is
+// used.
+type FileKeyProvider struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileKeyProvider creates a FileKeyProvider reading the key from path.
+//
+// Parameters:
+// - path: the path of the file holding the API key.
+//
+// Returns:
+// - *FileKeyProvider: the key provider.
+// - error: an error if the file cannot be read or is empty.
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	provider := &FileKeyProvider{path: path}
+	if _, err := provider.Key(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// Key returns the key held by the file.
+func (provider *FileKeyProvider) Key(ctx context.Context) (string, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	info, err := os.Stat(provider.path)
+	if err != nil {
+		return provider.lastKey(err)
+	}
+
+	if provider.key != "" && info.ModTime().Equal(provider.modTime) && info.Size() == provider.size {
+		return provider.key, nil
+	}
+
+	content, err := os.ReadFile(provider.path)
+	if err != nil {
+		return provider.lastKey(err)
+	}
+
+	key := strings.TrimSpace(string(content))
+	if key == "" {
+		return provider.lastKey(fmt.Errorf("%w: %s is empty", ErrMissingApiKey, provider.path))
+	}
+
+	provider.key = key
+	provider.modTime = info.ModTime()
+	provider.size = info.Size()
+
+	return key, nil
+}
+
+// lastKey returns the last key read, or err if none was. It must be called
+// with the lock held.
+func (provider *FileKeyProvider) lastKey(err error) (string, error) {
+	if provider.key == "" {
+		return "", err
+	}
+
+	return provider.key, nil
+}
+
+// KeyPool spreads requests over several API keys in round-robin order. A key
+// rejected by the API with an authentication or quota error is set aside for
+// a cooldown, and requests fail over to the next key.
+type KeyPool struct {
+	cooldown time.Duration
+
+	mu            sync.Mutex
+	keys          []string
+	disabledUntil []time.Time
+	next          int
+}
+
+var _ KeyFailover = (*KeyPool)(nil)
+
+// NewKeyPool creates a KeyPool.
+//
+// Parameters:
+// - cooldown: how long a rejected key is set aside, DefaultKeyCooldown if zero or less.
+// - keys: the API keys.
+//
+// Returns:
+// - *KeyPool: the key pool.
+// - error: ErrMissingApiKey if no key is given or a key is empty.
+func NewKeyPool(cooldown time.Duration, keys ...string) (*KeyPool, error) {
+	if len(keys) == 0 {
+		return nil, ErrMissingApiKey
+	}
+
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return nil, ErrMissingApiKey
+		}
+	}
+
+	if cooldown <= 0 {
+		cooldown = DefaultKeyCooldown
+	}
+
+	return &KeyPool{
+		cooldown:      cooldown,
+		keys:          append([]string(nil), keys...),
+		disabledUntil: make([]time.Time, len(keys)),
+	}, nil
+}
+
+// Key returns the next available key.
+func (pool *KeyPool) Key(ctx context.Context) (string, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+	for i := 0; i < len(pool.keys); i++ {
+		index := (pool.next + i) % len(pool.keys)
+		if now.Before(pool.disabledUntil[index]) {
+			continue
+		}
+
+		pool.next = (index + 1) % len(pool.keys)
+		return pool.keys[index], nil
+	}
+
+	return "", ErrNoAvailableKey
+}
+
+// Failover sets key aside for the cooldown of the pool, and returns whether
+// another key is available.
+func (pool *KeyPool) Failover(ctx context.Context, key string, err error) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+	available := false
+	for index, candidate := range pool.keys {
+		if candidate == key {
+			pool.disabledUntil[index] = now.Add(pool.cooldown)
+		} else if !now.Before(pool.disabledUntil[index]) {
+			available = true
+		}
+	}
+
+	return available
+}
+
+// isKeyRejected reports whether err means the key of the request was
+// rejected, so that another key may succeed.
+func isKeyRejected(err error) bool {
+	return IsAuthError(err) || IsQuotaExceeded(err)
+}
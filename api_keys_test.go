@@ -0,0 +1,197 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+)
+
+func TestKeyPoolFailsOver(t *testing.T) {
+	server := newTestServer(t, 3)
+	server.ExpectAPIKey("secret")
+
+	pool, err := metaphor.NewKeyPool(time.Minute, "revoked", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Failovers do not count against the retry policy, which allows a
+	// single attempt here.
+	client, err := server.NewClient(metaphor.WithKeyProvider(pool), metaphor.WithRetryPolicy(fastRetryPolicy(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Search(context.Background(), "golang"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := []string{}
+	for _, request := range server.Requests() {
+		keys = append(keys, request.Header.Get("x-api-key"))
+	}
+
+	// The revoked key is set aside for the cooldown.
+	if len(keys) != 3 || keys[0] != "revoked" || keys[1] != "secret" || keys[2] != "secret" {
+		t.Fatalf("sent keys %v", keys)
+	}
+}
+
+func TestKeyPoolAllKeysRejected(t *testing.T) {
+	server := newTestServer(t, 3)
+	server.ExpectAPIKey("secret")
+
+	pool, err := metaphor.NewKeyPool(time.Minute, "revoked-1", "revoked-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := server.NewClient(metaphor.WithKeyProvider(pool))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); !metaphor.IsAuthError(err) {
+		t.Fatalf("got %v, want an auth error", err)
+	}
+
+	if count := server.RequestCount(""); count != 2 {
+		t.Fatalf("got %d requests, want one per key", count)
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); !errors.Is(err, metaphor.ErrNoAvailableKey) {
+		t.Fatalf("got %v, want ErrNoAvailableKey", err)
+	}
+
+	if count := server.RequestCount(""); count != 2 {
+		t.Fatal("a request was sent without an available key")
+	}
+}
+
+func TestKeyPoolCooldown(t *testing.T) {
+	pool, err := metaphor.NewKeyPool(20*time.Millisecond, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if !pool.Failover(ctx, "a", metaphor.ErrMissingApiKey) {
+		t.Fatal("b should be available")
+	}
+
+	for i := 0; i < 2; i++ {
+		if key, _ := pool.Key(ctx); key != "b" {
+			t.Fatalf("got %q while a cools down", key)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		key, _ := pool.Key(ctx)
+		seen[key] = true
+	}
+
+	if !seen["a"] || !seen["b"] {
+		t.Fatalf("got keys %v after the cooldown, want both", seen)
+	}
+}
+
+func TestNewKeyPoolRejectsEmptyKeys(t *testing.T) {
+	if _, err := metaphor.NewKeyPool(0); !errors.Is(err, metaphor.ErrMissingApiKey) {
+		t.Fatalf("got %v, want ErrMissingApiKey", err)
+	}
+
+	if _, err := metaphor.NewKeyPool(0, "a", " "); !errors.Is(err, metaphor.ErrMissingApiKey) {
+		t.Fatalf("got %v, want ErrMissingApiKey", err)
+	}
+}
+
+func TestFileKeyProviderRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("key-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := metaphor.NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if key, err := provider.Key(ctx); err != nil || key != "key-1" {
+		t.Fatalf("got %q, %v", key, err)
+	}
+
+	if err := os.WriteFile(path, []byte("key-2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Make sure the change is seen on file systems with coarse timestamps.
+	modTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if key, err := provider.Key(ctx); err != nil || key != "key-2" {
+		t.Fatalf("got %q, %v after the rotation", key, err)
+	}
+
+	// The last key is kept while the file is missing.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if key, err := provider.Key(ctx); err != nil || key != "key-2" {
+		t.Fatalf("got %q, %v while the file is missing", key, err)
+	}
+}
+
+func TestNewFileKeyProviderErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := metaphor.NewFileKeyProvider(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v, want os.ErrNotExist", err)
+	}
+
+	path := filepath.Join(dir, "empty")
+	if err := os.WriteFile(path, []byte(" \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := metaphor.NewFileKeyProvider(path); !errors.Is(err, metaphor.ErrMissingApiKey) {
+		t.Fatalf("got %v, want ErrMissingApiKey", err)
+	}
+}
+
+func TestEnvKey(t *testing.T) {
+	provider := metaphor.EnvKey("METAPHOR_TEST_KEY")
+	ctx := context.Background()
+
+	t.Setenv("METAPHOR_TEST_KEY", " env-key\n")
+	if key, err := provider.Key(ctx); err != nil || key != "env-key" {
+		t.Fatalf("got %q, %v", key, err)
+	}
+
+	t.Setenv("METAPHOR_TEST_KEY", "")
+	if _, err := provider.Key(ctx); !errors.Is(err, metaphor.ErrMissingApiKey) {
+		t.Fatalf("got %v, want ErrMissingApiKey", err)
+	}
+}
+
+func TestMissingKey(t *testing.T) {
+	if _, err := metaphor.StaticKey("").Key(context.Background()); !errors.Is(err, metaphor.ErrMissingApiKey) {
+		t.Fatalf("got %v, want ErrMissingApiKey", err)
+	}
+
+	if _, err := metaphor.NewClient(""); !errors.Is(err, metaphor.ErrMissingApiKey) {
+		t.Fatalf("got %v, want ErrMissingApiKey", err)
+	}
+}
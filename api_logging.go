@@ -0,0 +1,225 @@
+package metaphor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// LogOptions controls what is logged by a client created with WithLogger.
+// The fields are used as given, and the zero slog.Level is slog.LevelInfo:
+// start from DefaultLogOptions and change the fields needed, rather than
+// from a LogOptions literal.
+type LogOptions struct {
+	// Level is the level of the records of requests and successful
+	// responses.
+	Level slog.Level
+
+	// RetryLevel is the level of the records of failed attempts that are
+	// retried.
+	RetryLevel slog.Level
+
+	// ErrorLevel is the level of the records of failed requests.
+	ErrorLevel slog.Level
+
+	// ResponseBodies logs the body of successful responses, which can be
+	// large when they hold contents.
+	ResponseBodies bool
+
+	// MaxExtractLength truncates the extracts, texts, summaries and
+	// highlights of logged bodies to this number of bytes. Zero means no
+	// truncation.
+	MaxExtractLength int
+}
+
+// DefaultLogOptions returns the log options used by WithLogger: requests and
+// responses are logged at debug level without response bodies, retries at
+// warning level and failures at error level.
+func DefaultLogOptions() LogOptions {
+	return LogOptions{
+		Level:            slog.LevelDebug,
+		RetryLevel:       slog.LevelWarn,
+		ErrorLevel:       slog.LevelError,
+		MaxExtractLength: 200,
+	}
+}
+
+// redactedHeaders lists the headers whose values are never logged.
+var redactedHeaders = []string{"x-api-key", "Authorization"}
+
+// truncatedFields lists the JSON fields holding document contents, which are
+// truncated in logged bodies.
+var truncatedFields = map[string]bool{
+	"extract":    true,
+	"text":       true,
+	"summary":    true,
+	"highlights": true,
+}
+
+// requestLog logs the lifecycle of a single runRequest call. A nil
+// requestLog logs nothing.
+type requestLog struct {
+	logger  *slog.Logger
+	options LogOptions
+	ctx     context.Context
+	start   time.Time
+	attrs   []slog.Attr
+}
+
+// newRequestLog returns the log of req, or nil when the call has no logger.
+func newRequestLog(config *requestConfig, req *http.Request) *requestLog {
+	if config.logger == nil {
+		return nil
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+	}
+	if req.URL.RawQuery != "" {
+		attrs = append(attrs, slog.String("query", req.URL.RawQuery))
+	}
+
+	return &requestLog{
+		logger:  config.logger,
+		options: config.logOptions,
+		ctx:     req.Context(),
+		start:   time.Now(),
+		attrs:   attrs[:len(attrs):len(attrs)],
+	}
+}
+
+// request logs the request about to be sent, with its redacted headers and
+// its body.
+func (log *requestLog) request(req *http.Request) {
+	if log == nil || !log.logger.Enabled(log.ctx, log.options.Level) {
+		return
+	}
+
+	attrs := append(log.attrs, slog.Any("headers", redactHeaders(req.Header)))
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			bytes, _ := io.ReadAll(body)
+			body.Close()
+			attrs = append(attrs, slog.String("body", log.sanitize(bytes)))
+		}
+	}
+
+	log.logger.LogAttrs(log.ctx, log.options.Level, "metaphor request", attrs...)
+}
+
+// response logs a successful response.
+func (log *requestLog) response(attempts int, body []byte) {
+	if log == nil || !log.logger.Enabled(log.ctx, log.options.Level) {
+		return
+	}
+
+	attrs := append(log.attrs,
+		slog.Int("status", http.StatusOK),
+		slog.Duration("latency", time.Since(log.start)),
+		slog.Int("attempts", attempts),
+		slog.Int("size", len(body)),
+	)
+	if log.options.ResponseBodies {
+		attrs = append(attrs, slog.String("body", log.sanitize(body)))
+	}
+
+	log.logger.LogAttrs(log.ctx, log.options.Level, "metaphor response", attrs...)
+}
+
+// retry logs a failed attempt that is about to be retried.
+func (log *requestLog) retry(attempt int, err error) {
+	if log == nil {
+		return
+	}
+
+	log.logger.LogAttrs(log.ctx, log.options.RetryLevel, "metaphor request retry", append(log.attrs,
+		slog.Int("status", statusCode(err)),
+		slog.Duration("latency", time.Since(log.start)),
+		slog.Int("attempt", attempt),
+		slog.String("error", err.Error()),
+	)...)
+}
+
+// failure logs a request that failed after its last attempt.
+func (log *requestLog) failure(attempts int, err error) {
+	if log == nil {
+		return
+	}
+
+	log.logger.LogAttrs(log.ctx, log.options.ErrorLevel, "metaphor request failed", append(log.attrs,
+		slog.Int("status", statusCode(err)),
+		slog.Duration("latency", time.Since(log.start)),
+		slog.Int("attempts", attempts),
+		slog.String("error", err.Error()),
+	)...)
+}
+
+// sanitize returns body with its contents truncated to MaxExtractLength.
+// Bodies that are not JSON are returned truncated as a whole.
+func (log *requestLog) sanitize(body []byte) string {
+	maxLength := log.options.MaxExtractLength
+	if maxLength <= 0 {
+		return string(body)
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return truncate(string(body), maxLength)
+	}
+
+	sanitized, err := json.Marshal(truncateContents(value, maxLength, false))
+	if err != nil {
+		return truncate(string(body), maxLength)
+	}
+
+	return string(sanitized)
+}
+
+// truncateContents truncates the strings of the content fields of a decoded
+// JSON value. inContent reports whether value is held by a content field.
+func truncateContents(value any, maxLength int, inContent bool) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			value[key] = truncateContents(field, maxLength, truncatedFields[key])
+		}
+	case []any:
+		for i, element := range value {
+			value[i] = truncateContents(element, maxLength, inContent)
+		}
+	case string:
+		if inContent {
+			return truncate(value, maxLength)
+		}
+	}
+
+	return value
+}
+
+func truncate(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+
+	for maxLength > 0 && !utf8.RuneStart(value[maxLength]) {
+		maxLength--
+	}
+
+	return value[:maxLength] + "..."
+}
+
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "REDACTED")
+		}
+	}
+
+	return redacted
+}
@@ -0,0 +1,214 @@
+package metaphor_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+// captureHandler is a slog.Handler keeping the records it handles.
+type captureHandler struct {
+	level slog.Level
+
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (handler *captureHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= handler.level
+}
+
+func (handler *captureHandler) Handle(ctx context.Context, record slog.Record) error {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	handler.records = append(handler.records, record.Clone())
+	return nil
+}
+
+func (handler *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return handler }
+
+func (handler *captureHandler) WithGroup(name string) slog.Handler { return handler }
+
+// messages returns the level and message of every record.
+func (handler *captureHandler) messages() []string {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	messages := []string{}
+	for _, record := range handler.records {
+		messages = append(messages, record.Level.String()+" "+record.Message)
+	}
+
+	return messages
+}
+
+// find returns the attributes of the first record with message.
+func (handler *captureHandler) find(t *testing.T, message string) map[string]slog.Value {
+	t.Helper()
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	for _, record := range handler.records {
+		if record.Message != message {
+			continue
+		}
+
+		attrs := map[string]slog.Value{}
+		record.Attrs(func(attr slog.Attr) bool {
+			attrs[attr.Key] = attr.Value
+			return true
+		})
+		return attrs
+	}
+
+	t.Fatalf("no %q record was logged", message)
+	return nil
+}
+
+func newLoggedClient(t *testing.T, server *metaphortest.Server, handler *captureHandler, options ...metaphor.ClientOptions) *metaphor.Client {
+	t.Helper()
+
+	client, err := server.NewClient(append([]metaphor.ClientOptions{metaphor.WithLogger(slog.New(handler))}, options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestLoggerRedactsAPIKey(t *testing.T) {
+	server := newTestServer(t, 3)
+	server.ExpectAPIKey("very-secret-key")
+	handler := &captureHandler{level: slog.LevelDebug}
+	client := newLoggedClient(t, server, handler)
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	request := handler.find(t, "metaphor request")
+	headers, ok := request["headers"].Any().(http.Header)
+	if !ok {
+		t.Fatalf("the headers were logged as %T", request["headers"].Any())
+	}
+
+	if got := headers.Get("x-api-key"); got != "REDACTED" {
+		t.Fatalf("x-api-key was logged as %q", got)
+	}
+
+	for _, record := range handler.records {
+		if strings.Contains(fmt.Sprint(record), "very-secret-key") {
+			t.Fatalf("the API key was logged in %q", record.Message)
+		}
+	}
+
+	if request["method"].String() != http.MethodPost || request["path"].String() != metaphor.DefaultSearchPath {
+		t.Fatalf("unexpected request record %v", request)
+	}
+
+	if !strings.Contains(request["body"].String(), `"query":"golang"`) {
+		t.Fatalf("the request body was logged as %q", request["body"].String())
+	}
+}
+
+func TestLoggerTruncatesExtracts(t *testing.T) {
+	server := newTestServer(t, 3)
+	handler := &captureHandler{level: slog.LevelDebug}
+
+	options := metaphor.DefaultLogOptions()
+	options.ResponseBodies = true
+	options.MaxExtractLength = 10
+	client := newLoggedClient(t, server, handler, metaphor.WithLogOptions(options))
+
+	response, err := client.GetContents(context.Background(), []string{"id-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extract := response.Contents[0].Extract
+	body := handler.find(t, "metaphor response")["body"].String()
+	if strings.Contains(body, extract) || !strings.Contains(body, extract[:10]+"...") {
+		t.Fatalf("the extract was not truncated in %q", body)
+	}
+
+	// The ID is not a content field and is kept whole.
+	if !strings.Contains(body, `"id":"id-0"`) {
+		t.Fatalf("the ID was truncated in %q", body)
+	}
+}
+
+func TestLoggerRetriesAndFailures(t *testing.T) {
+	server := newTestServer(t, 3)
+	handler := &captureHandler{level: slog.LevelDebug}
+	client := newLoggedClient(t, server, handler, metaphor.WithRetryPolicy(fastRetryPolicy(2)))
+	ctx := context.Background()
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusServiceUnavailable))
+	if _, err := client.Search(ctx, "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusBadRequest))
+	if _, err := client.Search(ctx, "golang"); err == nil {
+		t.Fatal("expected the search to fail")
+	}
+
+	want := []string{
+		"DEBUG metaphor request",
+		"WARN metaphor request retry",
+		"DEBUG metaphor response",
+		"DEBUG metaphor request",
+		"ERROR metaphor request failed",
+	}
+	if got := handler.messages(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got records %q, want %q", got, want)
+	}
+
+	retry := handler.find(t, "metaphor request retry")
+	if retry["status"].Int64() != http.StatusServiceUnavailable || retry["attempt"].Int64() != 1 {
+		t.Fatalf("unexpected retry record %v", retry)
+	}
+
+	if attempts := handler.find(t, "metaphor response")["attempts"].Int64(); attempts != 2 {
+		t.Fatalf("the response was logged after %d attempts, want 2", attempts)
+	}
+
+	failure := handler.find(t, "metaphor request failed")
+	if failure["status"].Int64() != http.StatusBadRequest || failure["error"].String() == "" {
+		t.Fatalf("unexpected failure record %v", failure)
+	}
+}
+
+func TestLoggerLevels(t *testing.T) {
+	server := newTestServer(t, 3)
+	handler := &captureHandler{level: slog.LevelInfo}
+
+	// Requests and responses are logged at debug level by default.
+	client := newLoggedClient(t, server, handler)
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := handler.messages(); len(got) != 0 {
+		t.Fatalf("got records %q above the debug level", got)
+	}
+
+	options := metaphor.DefaultLogOptions()
+	options.Level = slog.LevelInfo
+	if _, err := client.Search(context.Background(), "golang", metaphor.WithLogOptions(options)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := handler.messages(); len(got) != 2 || got[0] != "INFO metaphor request" {
+		t.Fatalf("got records %q, want the request and response at info level", got)
+	}
+}
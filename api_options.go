@@ -1,19 +1,28 @@
 package metaphor
 
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
 type RequestOptions struct {
-	NumResults          int      `json:"numResults,omitempty"`
-	IncludeDomains      []string `json:"includeDomains,omitempty"`
-	ExcludeDomains      []string `json:"excludeDomains,omitempty"`
-	StartCrawlDate      string   `json:"startCrawlDate,omitempty"`
-	EndCrawlDate        string   `json:"endCrawlDate,omitempty"`
-	StartPublishedDate  string   `json:"startPublishedDate,omitempty"`
-	EndPublishedDate    string   `json:"endPublishedDate,omitempty"`
-	ExcludeSourceDomain bool     `json:"excludeSourceDomain,omitempty"`
-	UseAutoprompt       bool     `json:"useAutoprompt,omitempty"`
-	Type                string   `json:"type,omitempty"`
+	NumResults          int        `json:"numResults,omitempty"`
+	IncludeDomains      []string   `json:"includeDomains,omitempty"`
+	ExcludeDomains      []string   `json:"excludeDomains,omitempty"`
+	StartCrawlDate      string     `json:"startCrawlDate,omitempty"`
+	EndCrawlDate        string     `json:"endCrawlDate,omitempty"`
+	StartPublishedDate  string     `json:"startPublishedDate,omitempty"`
+	EndPublishedDate    string     `json:"endPublishedDate,omitempty"`
+	ExcludeSourceDomain bool       `json:"excludeSourceDomain,omitempty"`
+	UseAutoprompt       bool       `json:"useAutoprompt,omitempty"`
+	Type                SearchType `json:"type,omitempty"`
 }
 
-type ClientOptions func(*Client)
+// ClientOptions customizes a request. Options passed to NewClient become the
+// client defaults and are applied to every call; options passed to a single
+// call are applied after them and only affect that call.
+type ClientOptions func(*requestConfig)
 
 // WithNumResults sets the number of expected search results.
 //
@@ -22,8 +31,8 @@ type ClientOptions func(*Client)
 //
 // Returns: a ClientOptions function that updates the numResults field of the RequestBody struct.
 func WithNumResults(numResults int) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.NumResults = numResults
+	return func(config *requestConfig) {
+		config.body.NumResults = numResults
 	}
 }
 
@@ -37,8 +46,8 @@ func WithNumResults(numResults int) ClientOptions {
 //
 // Returns: a ClientOptions function that updates the includeDomains field of the RequestBody struct.
 func WithIncludeDomains(includeDomains []string) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.IncludeDomains = includeDomains
+	return func(config *requestConfig) {
+		config.body.IncludeDomains = append([]string(nil), includeDomains...)
 	}
 }
 
@@ -51,74 +60,175 @@ func WithIncludeDomains(includeDomains []string) ClientOptions {
 //
 // Returns: a ClientOptions function that updates the excludeDomains field of the RequestBody struct.
 func WithExcludeDomains(excludeDomains []string) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.ExcludeDomains = excludeDomains
+	return func(config *requestConfig) {
+		config.body.ExcludeDomains = append([]string(nil), excludeDomains...)
 	}
 }
 
 // WithStartCrawlDate sets the start crawl date for the client options.
 // If startCrawlDate is specified, results will only include links that
 // were crawled after startCrawlDate.
-// Must be specified in ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ)
+// Must be specified in ISO 8601 format (YYYY-MM-DD or YYYY-MM-DDTHH:MM:SSZ),
+// see WithStartCrawlTime to pass a time.Time.
 //
 // Parameters:
 // - startCrawlDate: the start date for the crawl
 //
 // Returns: a ClientOptions function that updates the startCrawlDate field of the RequestBody struct.
 func WithStartCrawlDate(startCrawlDate string) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.StartCrawlDate = startCrawlDate
+	return func(config *requestConfig) {
+		config.body.StartCrawlDate = startCrawlDate
 	}
 }
 
 // WithEndCrawlDate sets the end crawl date for the client options.
 // If endCrawlDate is specified, results will only include links that
 // were crawled before endCrawlDate.
-// Must be specified in ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ)
+// Must be specified in ISO 8601 format (YYYY-MM-DD or YYYY-MM-DDTHH:MM:SSZ),
+// see WithEndCrawlTime to pass a time.Time.
 //
 // Parameters:
 // - endCrawlDate: the end crawl date to be set.
 //
 // Returns: a ClientOptions function that updates the endCrawlDate field of the RequestBody struct.
 func WithEndCrawlDate(endCrawlDate string) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.EndCrawlDate = endCrawlDate
+	return func(config *requestConfig) {
+		config.body.EndCrawlDate = endCrawlDate
 	}
 }
 
 // WithStartPublishedDate sets the start published date for the client options.
 // If specified, only links with a published date after startPublishedDate will
 // be returned.
-// Must be specified in ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ).
+// Must be specified in ISO 8601 format (YYYY-MM-DD or YYYY-MM-DDTHH:MM:SSZ),
+// see WithStartPublishedTime to pass a time.Time.
 //
 // Parameters:
 // - startPublishedDate: a string representing the start published date.
 //
 // Returns: a ClientOptions function that updates the startPublishedDate field of the RequestBody struct.
 func WithStartPublishedDate(startPublishedDate string) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.StartPublishedDate = startPublishedDate
+	return func(config *requestConfig) {
+		config.body.StartPublishedDate = startPublishedDate
 	}
 }
 
 // WithEndPublishedDate sets the end published date for the client options.
 // If specified, only links with a published date before endPublishedDate will
 // be returned.
-// Must be specified in ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ).
+// Must be specified in ISO 8601 format (YYYY-MM-DD or YYYY-MM-DDTHH:MM:SSZ),
+// see WithEndPublishedTime to pass a time.Time.
 //
 // Parameters:
 // - endPublishedDate: the end published date to be set.
 //
 // Returns: a ClientOptions function that updates the endPublishedDate field of the RequestBody struct.
 func WithEndPublishedDate(endPublishedDate string) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.EndPublishedDate = endPublishedDate
+	return func(config *requestConfig) {
+		config.body.EndPublishedDate = endPublishedDate
 	}
 }
 
+// WithStartCrawlTime is like WithStartCrawlDate but takes a time.Time.
+//
+// Parameters:
+// - startCrawlTime: the start time for the crawl.
+//
+// Returns: a ClientOptions function that updates the startCrawlDate field of the RequestBody struct.
+func WithStartCrawlTime(startCrawlTime time.Time) ClientOptions {
+	return WithStartCrawlDate(formatDate(startCrawlTime))
+}
+
+// WithEndCrawlTime is like WithEndCrawlDate but takes a time.Time.
+//
+// Parameters:
+// - endCrawlTime: the end time for the crawl.
+//
+// Returns: a ClientOptions function that updates the endCrawlDate field of the RequestBody struct.
+func WithEndCrawlTime(endCrawlTime time.Time) ClientOptions {
+	return WithEndCrawlDate(formatDate(endCrawlTime))
+}
+
+// WithStartPublishedTime is like WithStartPublishedDate but takes a time.Time.
+//
+// Parameters:
+// - startPublishedTime: the start published time.
+//
+// Returns: a ClientOptions function that updates the startPublishedDate field of the RequestBody struct.
+func WithStartPublishedTime(startPublishedTime time.Time) ClientOptions {
+	return WithStartPublishedDate(formatDate(startPublishedTime))
+}
 
-// If ExcludeSourceDomain is true, links from the base domain of the input will be 
-// automatically excluded from the results. 
+// WithEndPublishedTime is like WithEndPublishedDate but takes a time.Time.
+//
+// Parameters:
+// - endPublishedTime: the end published time.
+//
+// Returns: a ClientOptions function that updates the endPublishedDate field of the RequestBody struct.
+func WithEndPublishedTime(endPublishedTime time.Time) ClientOptions {
+	return WithEndPublishedDate(formatDate(endPublishedTime))
+}
+
+// CrawledBetween only includes links crawled between start and end. The
+// call fails if start does not precede end.
+//
+// Parameters:
+// - start: the start of the crawl period.
+// - end: the end of the crawl period.
+//
+// Returns: a ClientOptions function that updates the startCrawlDate and endCrawlDate fields of the RequestBody struct.
+func CrawledBetween(start time.Time, end time.Time) ClientOptions {
+	return func(config *requestConfig) {
+		config.body.StartCrawlDate = formatDate(start)
+		config.body.EndCrawlDate = formatDate(end)
+	}
+}
+
+// PublishedBetween only includes links published between start and end.
+// The call fails if start does not precede end.
+//
+// Parameters:
+// - start: the start of the publication period.
+// - end: the end of the publication period.
+//
+// Returns: a ClientOptions function that updates the startPublishedDate and endPublishedDate fields of the RequestBody struct.
+func PublishedBetween(start time.Time, end time.Time) ClientOptions {
+	return func(config *requestConfig) {
+		config.body.StartPublishedDate = formatDate(start)
+		config.body.EndPublishedDate = formatDate(end)
+	}
+}
+
+// CrawledWithin only includes links crawled during the last period. The
+// period is computed when each call is made, so the option can be passed to
+// NewClient.
+//
+// Parameters:
+// - period: how far back in time links were crawled.
+//
+// Returns: a ClientOptions function that updates the startCrawlDate field of the RequestBody struct.
+func CrawledWithin(period time.Duration) ClientOptions {
+	return func(config *requestConfig) {
+		config.body.StartCrawlDate = formatDate(time.Now().Add(-period))
+	}
+}
+
+// PublishedWithin only includes links published during the last period. The
+// period is computed when each call is made, so the option can be passed to
+// NewClient.
+//
+// Parameters:
+// - period: how far back in time links were published.
+//
+// Returns: a ClientOptions function that updates the startPublishedDate field of the RequestBody struct.
+func PublishedWithin(period time.Duration) ClientOptions {
+	return func(config *requestConfig) {
+		config.body.StartPublishedDate = formatDate(time.Now().Add(-period))
+	}
+}
+
+// If ExcludeSourceDomain is true, links from the base domain of the input will be
+// automatically excluded from the results.
 // Default: true
 //
 // Parameters:
@@ -126,8 +236,8 @@ func WithEndPublishedDate(endPublishedDate string) ClientOptions {
 //
 // Returns: a ClientOptions function that updates the ExcludeSourceDomain field in the RequestBody struct.
 func WithExcludeSourceDomain(excludeSourceDomain bool) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.ExcludeSourceDomain = excludeSourceDomain
+	return func(config *requestConfig) {
+		config.body.ExcludeSourceDomain = excludeSourceDomain
 	}
 }
 
@@ -140,22 +250,23 @@ func WithExcludeSourceDomain(excludeSourceDomain bool) ClientOptions {
 //
 // Returns: a ClientOptions function that updates the useAutoprompt field of the RequestBody struct.
 func WithAutoprompt(useAutoprompt bool) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.UseAutoprompt = useAutoprompt
+	return func(config *requestConfig) {
+		config.body.UseAutoprompt = useAutoprompt
 	}
 }
 
 // WithType sets the search type for the client.
-// Type of search, 'keyword' or 'neural'.
+// Type of search, SearchTypeNeural, SearchTypeKeyword or SearchTypeAuto to
+// let the client choose from the query.
 // Default: neural
 //
 // Parameters:
 // - searchType: the type of search to be performed.
 //
 // Returns: a ClientOptions function that updates the type field of the RequestBody struct.
-func WithType(searchType string) ClientOptions {
-	return func(client *Client) {
-		client.RequestBody.Type = searchType
+func WithType(searchType SearchType) ClientOptions {
+	return func(config *requestConfig) {
+		config.body.Type = searchType
 	}
 }
 
@@ -167,8 +278,8 @@ func WithType(searchType string) ClientOptions {
 //
 // Returns: a ClientOptions function that updates the baseURL field of the Client struct.
 func WithBaseURL(baseURL string) ClientOptions {
-	return func(client *Client) {
-		client.BaseURL = baseURL
+	return func(config *requestConfig) {
+		config.baseURL = baseURL
 	}
 }
 
@@ -178,45 +289,432 @@ func WithBaseURL(baseURL string) ClientOptions {
 // - reqOptions: The request options to be set of RequestOptions type.
 // Returns: a ClientOptions function that updates the RequestBody with additional options.
 func WithRequestOptions(reqOptions *RequestOptions) ClientOptions {
-	return func(client *Client) {
+	// Copy the options so later changes by the caller do not leak into
+	// requests that are already configured.
+	snapshot := *reqOptions
+	reqOptions = &snapshot
+
+	return func(config *requestConfig) {
 		if reqOptions.EndCrawlDate != "" {
-			client.RequestBody.EndCrawlDate = reqOptions.EndCrawlDate
+			config.body.EndCrawlDate = reqOptions.EndCrawlDate
 		}
 
 		if reqOptions.EndPublishedDate != "" {
-			client.RequestBody.EndPublishedDate = reqOptions.EndPublishedDate
+			config.body.EndPublishedDate = reqOptions.EndPublishedDate
 		}
 
 		if reqOptions.StartCrawlDate != "" {
-			client.RequestBody.StartCrawlDate = reqOptions.StartCrawlDate
+			config.body.StartCrawlDate = reqOptions.StartCrawlDate
 		}
 
 		if reqOptions.StartPublishedDate != "" {
-			client.RequestBody.StartPublishedDate = reqOptions.StartPublishedDate
+			config.body.StartPublishedDate = reqOptions.StartPublishedDate
 		}
 
 		if reqOptions.ExcludeSourceDomain {
-			client.RequestBody.ExcludeSourceDomain = reqOptions.ExcludeSourceDomain
+			config.body.ExcludeSourceDomain = reqOptions.ExcludeSourceDomain
 		}
 
 		if reqOptions.UseAutoprompt {
-			client.RequestBody.UseAutoprompt = reqOptions.UseAutoprompt
+			config.body.UseAutoprompt = reqOptions.UseAutoprompt
 		}
 
 		if reqOptions.Type != "" {
-			client.RequestBody.Type = reqOptions.Type
+			config.body.Type = reqOptions.Type
 		}
 
 		if reqOptions.NumResults != 0 {
-			client.RequestBody.NumResults = reqOptions.NumResults
+			config.body.NumResults = reqOptions.NumResults
 		}
 
 		if reqOptions.ExcludeDomains != nil {
-			client.RequestBody.ExcludeDomains = reqOptions.ExcludeDomains
+			config.body.ExcludeDomains = append([]string(nil), reqOptions.ExcludeDomains...)
 		}
 
 		if reqOptions.IncludeDomains != nil {
-			client.RequestBody.IncludeDomains = reqOptions.IncludeDomains
+			config.body.IncludeDomains = append([]string(nil), reqOptions.IncludeDomains...)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// WithHTTPClient sets the http.Client used to send requests, allowing
+// timeouts, proxies, TLS configuration and connection pooling to be
+// customized. The client is copied, so later changes to it have no effect.
+// Only takes effect when passed to NewClient.
+// Default: http.DefaultClient
+//
+// Parameters:
+// - httpClient: the http.Client to send requests with.
+//
+// Returns: a ClientOptions function that sets the http.Client of the Client.
+func WithHTTPClient(httpClient *http.Client) ClientOptions {
+	return func(config *requestConfig) {
+		config.httpClient = httpClient
+	}
+}
+
+// WithTransport sets the http.RoundTripper used to send requests. It replaces
+// the transport of the http.Client set with WithHTTPClient.
+// Only takes effect when passed to NewClient.
+// Default: http.DefaultTransport
+//
+// Parameters:
+// - transport: the http.RoundTripper to send requests with.
+//
+// Returns: a ClientOptions function that sets the transport of the Client.
+func WithTransport(transport http.RoundTripper) ClientOptions {
+	return func(config *requestConfig) {
+		config.transport = transport
+	}
+}
+
+// WithTimeout sets the timeout of every HTTP request sent by the Client,
+// including reading the response body. Each retry gets its own timeout. It
+// overrides the timeout of the http.Client set with WithHTTPClient.
+// Only takes effect when passed to NewClient.
+// Default: no timeout
+//
+// Parameters:
+// - timeout: the timeout of each HTTP request, zero or less keeps the default.
+//
+// Returns: a ClientOptions function that sets the timeout of the Client.
+func WithTimeout(timeout time.Duration) ClientOptions {
+	return func(config *requestConfig) {
+		config.timeout = timeout
+	}
+}
+
+// WithMiddleware appends middlewares wrapping the client transport. The first
+// middleware passed to the client is the outermost one and sees each request
+// first. Only takes effect when passed to NewClient.
+//
+// Parameters:
+// - middlewares: the middlewares to wrap the transport with.
+//
+// Returns: a ClientOptions function that appends middlewares to the Client.
+func WithMiddleware(middlewares ...Middleware) ClientOptions {
+	return func(config *requestConfig) {
+		config.middlewares = append(config.middlewares, middlewares...)
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed idempotent requests.
+// When passed to NewClient it applies to every call, and it can be overridden
+// for a single call.
+// Default: no retries
+//
+// Parameters:
+// - policy: the retry policy, see DefaultRetryPolicy for sensible values.
+//
+// Returns: a ClientOptions function that sets the retry policy of the request.
+func WithRetryPolicy(policy RetryPolicy) ClientOptions {
+	policy.RetryableStatusCodes = append([]int(nil), policy.RetryableStatusCodes...)
+
+	return func(config *requestConfig) {
+		config.retryPolicy = policy
+	}
+}
+
+// WithRateLimit limits the rate of requests sent by the client with a token
+// bucket shared by all its calls. Calls block until a request can be sent or
+// their context is done. Every retry attempt counts as a request.
+// Only takes effect when passed to NewClient.
+// Default: no limit
+//
+// Parameters:
+// - requestsPerSecond: the sustained number of requests per second.
+// - burst: the number of requests that can be sent at once after a pause.
+//
+// Returns: a ClientOptions function that sets the rate limit of the Client.
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOptions {
+	return func(config *requestConfig) {
+		config.rateLimit = requestsPerSecond
+		config.rateBurst = burst
+	}
+}
+
+// WithMaxInFlight limits the number of requests the client sends
+// concurrently. Calls block until a slot is free or their context is done.
+// Only takes effect when passed to NewClient.
+// Default: no limit
+//
+// Parameters:
+// - maxInFlight: the maximum number of concurrent requests.
+//
+// Returns: a ClientOptions function that sets the concurrency limit of the Client.
+func WithMaxInFlight(maxInFlight int) ClientOptions {
+	return func(config *requestConfig) {
+		config.maxInFlight = maxInFlight
+	}
+}
+
+// WithCache caches the responses of Search, FindSimilar and GetContents.
+// Responses are keyed by a hash of the endpoint and of the request, with
+// domains and IDs sorted. Only successful responses are cached.
+// Only takes effect when passed to NewClient.
+// Default: no cache
+//
+// Parameters:
+// - cache: the cache, such as a MemoryCache or a FileCache.
+//
+// Returns: a ClientOptions function that sets the cache of the Client.
+func WithCache(cache Cache) ClientOptions {
+	return func(config *requestConfig) {
+		config.cache = cache
+	}
+}
+
+// WithCacheBypass makes a call ignore the client cache: the response is
+// neither read from nor written to it.
+//
+// Returns: a ClientOptions function that disables the cache for the request.
+func WithCacheBypass() ClientOptions {
+	return func(config *requestConfig) {
+		config.cacheMode = cacheBypass
+	}
+}
+
+// WithCacheRefresh makes a call skip the cache lookup and always hit the
+// API, storing the fresh response in the cache.
+//
+// Returns: a ClientOptions function that refreshes the cached response of the request.
+func WithCacheRefresh() ClientOptions {
+	return func(config *requestConfig) {
+		config.cacheMode = cacheRefresh
+	}
+}
+
+// WithContentStore keeps every document returned by GetContents in store,
+// keyed by document ID. Later GetContents calls serve the known documents
+// from the store and only request the missing IDs from the API. The
+// WithCacheBypass and WithCacheRefresh options also apply to the store.
+// Only takes effect when passed to NewClient.
+// Default: no content store
+//
+// Parameters:
+// - store: the cache holding the documents, such as a MemoryCache or a FileCache.
+//
+// Returns: a ClientOptions function that sets the content store of the Client.
+func WithContentStore(store Cache) ClientOptions {
+	return func(config *requestConfig) {
+		config.store = store
+	}
+}
+
+// WithLogger logs every request sent to the API with logger: its method,
+// path, headers, body, status, latency and retry attempts. The x-api-key
+// header is always redacted. Can be passed to NewClient or to a single call.
+// Default: no logging
+//
+// Parameters:
+// - logger: the structured logger.
+//
+// Returns: a ClientOptions function that sets the logger of the request.
+func WithLogger(logger *slog.Logger) ClientOptions {
+	return func(config *requestConfig) {
+		config.logger = logger
+	}
+}
+
+// WithLogOptions sets the levels of the records logged by WithLogger, and
+// whether response bodies are logged and their contents truncated. The
+// options replace DefaultLogOptions as a whole, so unset levels are
+// slog.LevelInfo.
+// Default: DefaultLogOptions()
+//
+// Parameters:
+// - options: the log options.
+//
+// Returns: a ClientOptions function that sets the log options of the request.
+func WithLogOptions(options LogOptions) ClientOptions {
+	return func(config *requestConfig) {
+		config.logOptions = options
+	}
+}
+
+// WithBeforeRequest adds a hook called before every call is validated and
+// sent. Hooks can rewrite the body of the call, add headers to its requests,
+// or veto it by returning an error. Hooks run in the order they were added,
+// those passed to NewClient first.
+//
+// Parameters:
+// - hook: the hook.
+//
+// Returns: a ClientOptions function that adds the hook to the request.
+func WithBeforeRequest(hook BeforeRequestHook) ClientOptions {
+	return func(config *requestConfig) {
+		config.beforeRequestHooks = append(config.beforeRequestHooks[:len(config.beforeRequestHooks):len(config.beforeRequestHooks)], hook)
+	}
+}
+
+// WithAfterResponse adds a hook called once every call has decoded its
+// response, or failed. Hooks can audit or annotate the response, or reject
+// it by returning an error. Hooks run in the order they were added, those
+// passed to NewClient first.
+//
+// Parameters:
+// - hook: the hook.
+//
+// Returns: a ClientOptions function that adds the hook to the request.
+func WithAfterResponse(hook AfterResponseHook) ClientOptions {
+	return func(config *requestConfig) {
+		config.afterResponseHooks = append(config.afterResponseHooks[:len(config.afterResponseHooks):len(config.afterResponseHooks)], hook)
+	}
+}
+
+// WithKeyProvider makes the Client ask provider for the API key of every
+// request, instead of using the key given to NewClient, which can then be
+// empty. Providers implementing KeyFailover, such as KeyPool, fail over to
+// another key when the API rejects one.
+// Only takes effect when passed to NewClient.
+// Default: StaticKey of the key given to NewClient
+//
+// Parameters:
+// - provider: the key provider, such as EnvKey, a FileKeyProvider or a KeyPool.
+//
+// Returns: a ClientOptions function that sets the key provider of the Client.
+func WithKeyProvider(provider KeyProvider) ClientOptions {
+	return func(config *requestConfig) {
+		config.keyProvider = provider
+	}
+}
+
+// WithInstrumentation reports every call of the Client and every request it
+// sends to instrumentation, for tracing and metrics.
+// Only takes effect when passed to NewClient.
+// Default: no instrumentation
+//
+// Parameters:
+// - instrumentation: the instrumentation, such as the one of the otelmetaphor module.
+//
+// Returns: a ClientOptions function that sets the instrumentation of the Client.
+func WithInstrumentation(instrumentation Instrumentation) ClientOptions {
+	return func(config *requestConfig) {
+		config.instrumentation = instrumentation
+	}
+}
+
+// WithContentsBatchSize sets the maximum number of IDs sent in a single
+// contents request. Larger ID lists are split into several requests.
+// Default: 100
+//
+// Parameters:
+// - batchSize: the maximum number of IDs per request, zero disables batching.
+//
+// Returns: a ClientOptions function that sets the contents batch size of the request.
+func WithContentsBatchSize(batchSize int) ClientOptions {
+	return func(config *requestConfig) {
+		config.contentsBatchSize = batchSize
+	}
+}
+
+// WithContentsConcurrency sets the maximum number of contents batches
+// fetched concurrently by a single GetContents call.
+// Default: 4
+//
+// Parameters:
+// - concurrency: the maximum number of concurrent batch requests.
+//
+// Returns: a ClientOptions function that sets the contents concurrency of the request.
+func WithContentsConcurrency(concurrency int) ClientOptions {
+	return func(config *requestConfig) {
+		config.contentsConcurrency = concurrency
+	}
+}
+
+// WithContentsMethod sets how IDs are sent to the contents endpoint: as URL
+// encoded query parameters with http.MethodGet, or as a JSON body with
+// http.MethodPost, which is not limited by the maximum URL length.
+// Default: http.MethodGet
+//
+// Parameters:
+// - method: http.MethodGet or http.MethodPost.
+//
+// Returns: a ClientOptions function that sets the contents method of the request.
+func WithContentsMethod(method string) ClientOptions {
+	return func(config *requestConfig) {
+		config.contentsMethod = method
+	}
+}
+
+// WithText asks for the text of the documents, in GetContents and in the
+// results of SearchAndContents and FindSimilarAndContents.
+//
+// Parameters:
+// - options: the text options, the zero value returns the full text without HTML tags.
+//
+// Returns: a ClientOptions function that sets the text options of the request.
+func WithText(options TextOptions) ClientOptions {
+	return func(config *requestConfig) {
+		config.body.Contents = withContentsOptions(config.body.Contents)
+		config.body.Contents.Text = &options
+	}
+}
+
+// WithHighlights asks for the sentences of the documents most relevant to a
+// query, in GetContents and in the results of SearchAndContents and
+// FindSimilarAndContents.
+//
+// Parameters:
+// - options: the highlights options.
+//
+// Returns: a ClientOptions function that sets the highlights options of the request.
+func WithHighlights(options HighlightsOptions) ClientOptions {
+	return func(config *requestConfig) {
+		config.body.Contents = withContentsOptions(config.body.Contents)
+		config.body.Contents.Highlights = &options
+	}
+}
+
+// WithSummary asks for a summary of the documents, in GetContents and in the
+// results of SearchAndContents and FindSimilarAndContents.
+//
+// Parameters:
+// - options: the summary options.
+//
+// Returns: a ClientOptions function that sets the summary options of the request.
+func WithSummary(options SummaryOptions) ClientOptions {
+	return func(config *requestConfig) {
+		config.body.Contents = withContentsOptions(config.body.Contents)
+		config.body.Contents.Summary = &options
+	}
+}
+
+// withContentsOptions returns a copy of options, or new options if nil, so
+// that options set by one call never leak into another.
+func withContentsOptions(options *ContentsOptions) *ContentsOptions {
+	if options == nil {
+		return &ContentsOptions{}
+	}
+
+	copied := *options
+
+	return &copied
+}
+
+// WithPartialContents makes SearchAndContents and FindSimilarAndContents
+// return every result when the contents of some of them could not be
+// retrieved, instead of failing. The error is still returned, listing the
+// results left without an extract.
+// Default: false
+//
+// Returns: a ClientOptions function that enables partial contents for the request.
+func WithPartialContents() ClientOptions {
+	return func(config *requestConfig) {
+		config.partialContents = true
+	}
+}
+
+// WithStreamBufferSize sets the number of items buffered by SearchStream and
+// FindSimilarStream before they block waiting for the consumer.
+// Default: 100
+//
+// Parameters:
+// - size: the number of buffered items.
+//
+// Returns: a ClientOptions function that sets the stream buffer size of the request.
+func WithStreamBufferSize(size int) ClientOptions {
+	return func(config *requestConfig) {
+		config.streamBufferSize = size
+	}
+}
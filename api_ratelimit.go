@@ -0,0 +1,167 @@
+package metaphor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LimiterStats is a snapshot of the client-side rate limiter and concurrency
+// governor configured with WithRateLimit and WithMaxInFlight.
+type LimiterStats struct {
+	// Waiting is the number of requests currently blocked by the limiter.
+	Waiting int64
+
+	// InFlight is the number of requests currently being sent.
+	InFlight int64
+
+	// Acquired is the total number of requests let through by the limiter.
+	Acquired int64
+
+	// Delayed is the total number of requests that had to wait.
+	Delayed int64
+
+	// TotalWait is the time spent waiting by all requests.
+	TotalWait time.Duration
+}
+
+// limiter combines a token bucket rate limiter with a semaphore bounding the
+// number of requests in flight. It is shared by all the calls of a Client.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// slots holds one element per request in flight, it is nil when the
+	// number of requests in flight is not bounded.
+	slots chan struct{}
+
+	waiting   atomic.Int64
+	inFlight  atomic.Int64
+	acquired  atomic.Int64
+	delayed   atomic.Int64
+	totalWait atomic.Int64
+}
+
+// newLimiter returns a limiter allowing rate requests per second with bursts
+// of up to burst requests, and at most maxInFlight concurrent requests. It
+// returns nil when neither limit is set.
+func newLimiter(rate float64, burst int, maxInFlight int) *limiter {
+	if rate <= 0 && maxInFlight <= 0 {
+		return nil
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+
+	return l
+}
+
+// acquire blocks until a request can be sent or the context is done. The
+// returned function must be called once the request completes.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if delay := l.reserve(); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			l.cancelReservation()
+			l.releaseSlot()
+			return nil, ctx.Err()
+		}
+	}
+
+	if wait := time.Since(start); wait > time.Millisecond {
+		l.delayed.Add(1)
+		l.totalWait.Add(int64(wait))
+	}
+	l.acquired.Add(1)
+	l.inFlight.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.inFlight.Add(-1)
+			l.releaseSlot()
+		})
+	}, nil
+}
+
+// reserve takes a token from the bucket and returns how long the caller has
+// to wait before using it. The bucket goes negative while tokens are
+// reserved ahead of time.
+func (l *limiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancelReservation gives back a token reserved by a request that was
+// canceled before being sent.
+func (l *limiter) cancelReservation() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens++
+}
+
+func (l *limiter) releaseSlot() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+func (l *limiter) stats() LimiterStats {
+	return LimiterStats{
+		Waiting:   l.waiting.Load(),
+		InFlight:  l.inFlight.Load(),
+		Acquired:  l.acquired.Load(),
+		Delayed:   l.delayed.Load(),
+		TotalWait: time.Duration(l.totalWait.Load()),
+	}
+}
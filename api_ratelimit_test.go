@@ -0,0 +1,121 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+)
+
+func TestMaxInFlightBoundsConcurrency(t *testing.T) {
+	server := newTestServer(t, 3)
+	server.SetLatency(20 * time.Millisecond)
+
+	var inFlight, maxInFlight atomic.Int32
+	transport := metaphor.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			highest := maxInFlight.Load()
+			if current <= highest || maxInFlight.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	client, err := server.NewClient(metaphor.WithTransport(transport), metaphor.WithMaxInFlight(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Search(context.Background(), "golang"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got > 2 {
+		t.Fatalf("got %d requests in flight, want at most 2", got)
+	}
+
+	stats := client.LimiterStats()
+	if stats.Acquired != 10 || stats.InFlight != 0 || stats.Waiting != 0 || stats.Delayed == 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestRateLimitSpacesRequests(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithRateLimit(20, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := client.Search(context.Background(), "golang"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The burst lets 2 requests through, the 3 others wait 50ms each.
+	if elapsed := time.Since(start); elapsed < 120*time.Millisecond {
+		t.Fatalf("5 requests took %v, want about 150ms", elapsed)
+	}
+
+	stats := client.LimiterStats()
+	if stats.Acquired != 5 || stats.Delayed < 3 || stats.TotalWait <= 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestRateLimitHonorsContext(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithRateLimit(0.1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.Search(ctx, "golang"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want a deadline error", err)
+	}
+
+	if count := server.RequestCount(""); count != 1 {
+		t.Fatalf("got %d requests, want 1", count)
+	}
+}
+
+func TestLimiterStatsWithoutLimits(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := client.LimiterStats(); stats != (metaphor.LimiterStats{}) {
+		t.Fatalf("got %+v, want zero stats", stats)
+	}
+}
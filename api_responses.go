@@ -1,26 +1,62 @@
 package metaphor
 
-import "context"
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Result is a single result of a search or find similar request.
+type Result struct {
+	ID            string  `json:"id"`
+	URL           string  `json:"url"`
+	Title         string  `json:"title"`
+	PublishedDate string  `json:"publishedDate"`
+	Author        string  `json:"author"`
+	Score         float64 `json:"score"`
+	Extract       string  `json:"extract,omitempty"`
+
+	// The fields below are set when contents are requested inline, see
+	// SearchAndContents and ContentsOptions.
+	Text            string    `json:"text,omitempty"`
+	Highlights      []string  `json:"highlights,omitempty"`
+	HighlightScores []float64 `json:"highlightScores,omitempty"`
+	Summary         string    `json:"summary,omitempty"`
+}
+
+// Content is the content of a single document returned by GetContents.
+type Content struct {
+	ID      string `json:"id"`
+	URL     string `json:"url"`
+	Title   string `json:"title"`
+	Extract string `json:"extract"`
+
+	// The fields below are set according to the ContentsOptions of the
+	// request.
+	Text            string    `json:"text,omitempty"`
+	Highlights      []string  `json:"highlights,omitempty"`
+	HighlightScores []float64 `json:"highlightScores,omitempty"`
+	Summary         string    `json:"summary,omitempty"`
+}
 
 type SearchResponse struct {
-	Results []struct {
-		ID            string  `json:"id"`
-		URL           string  `json:"url"`
-		Title         string  `json:"title"`
-		PublishedDate string  `json:"publishedDate"`
-		Author        string  `json:"author"`
-		Score         float64 `json:"score"`
-		Extract       string
-	} `json:"results"`
+	Results []Result `json:"results"`
+
+	// Type is the type of search that was performed. When searching with
+	// SearchTypeAuto, it holds the type chosen by the client. It is empty
+	// for FindSimilar responses.
+	Type SearchType `json:"type,omitempty"`
+
+	// Annotations are set by hooks, see Annotate.
+	Annotations map[string]string `json:"-"`
 }
 
 type ContentsResponse struct {
-	Contents []struct {
-		ID      string `json:"id"`
-		URL     string `json:"url"`
-		Title   string `json:"title"`
-		Extract string `json:"extract"`
-	} `json:"contents"`
+	Contents []Content `json:"contents"`
+
+	// Annotations are set by hooks, see Annotate.
+	Annotations map[string]string `json:"-"`
 }
 
 type ErrorResponse struct {
@@ -32,14 +68,89 @@ type ErrorResponse struct {
 // Parameters:
 // - ctx: the context.Context for the request.
 // - client: The Metaphor client used for the request.
+// - options: Optional client options.
 //
 // Returns:
 // - *ContentsResponse: The contents response object.
 // - error: An error if the contents retrieval fails.
-func (response SearchResponse) GetContents(ctx context.Context, client *Client) (*ContentsResponse, error) {
-	ids := []string{}
+func (response SearchResponse) GetContents(ctx context.Context, client *Client, options ...ClientOptions) (*ContentsResponse, error) {
+	return client.GetContents(ctx, response.IDs(), options...)
+}
+
+// IDs returns the IDs of the results, in order.
+func (response SearchResponse) IDs() []string {
+	ids := make([]string, 0, len(response.Results))
 	for _, result := range response.Results {
 		ids = append(ids, result.ID)
 	}
-	return client.GetContents(ctx, ids)
+
+	return ids
+}
+
+// ByID returns the contents indexed by document ID.
+func (response ContentsResponse) ByID() map[string]Content {
+	contents := make(map[string]Content, len(response.Contents))
+	for _, content := range response.Contents {
+		contents[content.ID] = content
+	}
+
+	return contents
+}
+
+// Domain returns the host name of the result URL, without the "www." prefix.
+func (result Result) Domain() string {
+	return domainOf(result.URL)
+}
+
+// PublishedTime parses the published date of the result, which the API
+// returns either as a date or as an ISO 8601 timestamp.
+//
+// Returns:
+// - time.Time: The published date.
+// - error: An error if the result has no published date or if it cannot be parsed.
+func (result Result) PublishedTime() (time.Time, error) {
+	return parseDate(result.PublishedDate)
+}
+
+// ToContent converts the result to a Content holding the same document.
+func (result Result) ToContent() Content {
+	return Content{
+		ID:              result.ID,
+		URL:             result.URL,
+		Title:           result.Title,
+		Extract:         result.Extract,
+		Text:            result.Text,
+		Highlights:      result.Highlights,
+		HighlightScores: result.HighlightScores,
+		Summary:         result.Summary,
+	}
+}
+
+// Domain returns the host name of the content URL, without the "www." prefix.
+func (content Content) Domain() string {
+	return domainOf(content.URL)
+}
+
+// ToResult converts the content to a Result holding the same document. The
+// fields only known to search results, such as the score, are left empty.
+func (content Content) ToResult() Result {
+	return Result{
+		ID:              content.ID,
+		URL:             content.URL,
+		Title:           content.Title,
+		Extract:         content.Extract,
+		Text:            content.Text,
+		Highlights:      content.Highlights,
+		HighlightScores: content.HighlightScores,
+		Summary:         content.Summary,
+	}
+}
+
+func domainOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
 }
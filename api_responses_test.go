@@ -0,0 +1,80 @@
+package metaphor_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+)
+
+func TestResultDomain(t *testing.T) {
+	tests := map[string]string{
+		"https://www.Go.dev/doc":        "go.dev",
+		"https://WWW.EXAMPLE.COM:8080/": "example.com",
+		"https://blog.example.com/a":    "blog.example.com",
+		"https://www2.example.com":      "www2.example.com",
+		"not a url":                     "",
+		"://broken":                     "",
+	}
+
+	for rawURL, want := range tests {
+		if got := (metaphor.Result{URL: rawURL}).Domain(); got != want {
+			t.Errorf("Result{URL: %q}.Domain() = %q, want %q", rawURL, got, want)
+		}
+
+		if got := (metaphor.Content{URL: rawURL}).Domain(); got != want {
+			t.Errorf("Content{URL: %q}.Domain() = %q, want %q", rawURL, got, want)
+		}
+	}
+}
+
+func TestResultPublishedTime(t *testing.T) {
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{date: "2023-06-15", want: time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{date: "2023-06-15T10:20:30.000Z", want: time.Date(2023, 6, 15, 10, 20, 30, 0, time.UTC)},
+		{date: "2023-06-15T12:20:30+02:00", want: time.Date(2023, 6, 15, 10, 20, 30, 0, time.UTC)},
+		{date: "2023-06-15T10:20:30", want: time.Date(2023, 6, 15, 10, 20, 30, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		got, err := metaphor.Result{PublishedDate: test.date}.PublishedTime()
+		if err != nil || !got.Equal(test.want) {
+			t.Errorf("PublishedTime(%q) = %v, %v, want %v", test.date, got, err, test.want)
+		}
+	}
+
+	if _, err := (metaphor.Result{}).PublishedTime(); !errors.Is(err, metaphor.ErrMissingDate) {
+		t.Errorf("got %v, want ErrMissingDate", err)
+	}
+
+	if _, err := (metaphor.Result{PublishedDate: "June 2023"}).PublishedTime(); !errors.Is(err, metaphor.ErrInvalidDate) {
+		t.Errorf("got %v, want ErrInvalidDate", err)
+	}
+}
+
+func TestResultContentRoundTrip(t *testing.T) {
+	content := metaphor.Content{
+		ID:              "id-0",
+		URL:             "https://go.dev/doc",
+		Title:           "Documentation",
+		Extract:         "The Go programming language.",
+		Text:            "The Go programming language is an open source project.",
+		Highlights:      []string{"open source project"},
+		HighlightScores: []float64{0.9},
+		Summary:         "Go is open source.",
+	}
+
+	if got := content.ToResult().ToContent(); !reflect.DeepEqual(got, content) {
+		t.Fatalf("got %+v after a round trip, want %+v", got, content)
+	}
+
+	result := content.ToResult()
+	if result.Score != 0 || result.PublishedDate != "" || result.Author != "" {
+		t.Fatalf("ToResult set the search fields: %+v", result)
+	}
+}
@@ -0,0 +1,150 @@
+package metaphor
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Only idempotent calls
+// such as Search, FindSimilar and GetContents are retried. The zero value
+// disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values lower than 2 disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. It doubles after every
+	// failed attempt.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between two attempts. Zero means no cap.
+	MaxDelay time.Duration
+
+	// Jitter is the fraction of each delay, between 0 and 1, that is
+	// randomized to avoid many clients retrying at the same time.
+	Jitter float64
+
+	// RetryableStatusCodes lists the HTTP status codes that are retried.
+	RetryableStatusCodes []int
+
+	// RetryNetworkErrors retries requests that failed before a response was
+	// received, such as connection resets or timeouts.
+	RetryNetworkErrors bool
+}
+
+// DefaultRetryPolicy returns a retry policy suitable for most uses: up to 4
+// attempts with exponential backoff starting at 500ms, retrying rate limited
+// requests, server errors and network errors.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryNetworkErrors: true,
+	}
+}
+
+// shouldRetry reports whether the error of a failed attempt can be retried.
+func (policy RetryPolicy) shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return policy.RetryNetworkErrors
+	}
+
+	for _, statusCode := range policy.RetryableStatusCodes {
+		if apiErr.StatusCode == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns the delay to wait after the given failed attempt.
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < attempt && (policy.MaxDelay <= 0 || delay < policy.MaxDelay); i++ {
+		delay *= 2
+	}
+
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	if policy.Jitter > 0 {
+		jitter := policy.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+
+	return delay
+}
+
+// wait blocks until the next attempt can be sent. The Retry-After header of
+// the failed attempt takes precedence over the backoff when it asks for a
+// longer delay. An error is returned without waiting when the context would
+// expire before the next attempt.
+func (policy RetryPolicy) wait(ctx context.Context, attempt int, err error) error {
+	delay := policy.backoff(attempt)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+		delay = apiErr.RetryAfter
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, given either in
+// seconds or as an HTTP date. It returns zero when the value is missing or
+// invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,160 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+// fastRetryPolicy is DefaultRetryPolicy without its delays.
+func fastRetryPolicy(maxAttempts int) metaphor.RetryPolicy {
+	policy := metaphor.DefaultRetryPolicy()
+	policy.MaxAttempts = maxAttempts
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 5 * time.Millisecond
+	policy.Jitter = 0
+
+	return policy
+}
+
+func TestRetryPolicyRetriesServerErrors(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithRetryPolicy(fastRetryPolicy(4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(2, metaphortest.StatusFailure(http.StatusServiceUnavailable))
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := server.RequestCount(metaphor.DefaultSearchPath); count != 3 {
+		t.Fatalf("got %d requests, want 3", count)
+	}
+}
+
+func TestRetryPolicyGivesUpAfterMaxAttempts(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithRetryPolicy(fastRetryPolicy(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(5, metaphortest.StatusFailure(http.StatusBadGateway))
+	_, err = client.Search(context.Background(), "golang")
+
+	var apiErr *metaphor.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("got %v, want a 502 APIError", err)
+	}
+
+	if count := server.RequestCount(""); count != 3 {
+		t.Fatalf("got %d requests, want 3", count)
+	}
+}
+
+func TestRetryPolicyDoesNotRetryClientErrors(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithRetryPolicy(fastRetryPolicy(4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusBadRequest))
+	if _, err := client.Search(context.Background(), "golang"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if count := server.RequestCount(""); count != 1 {
+		t.Fatalf("got %d requests, want 1", count)
+	}
+}
+
+func TestRetryPolicyDisabledByDefault(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusServiceUnavailable))
+	if _, err := client.Search(context.Background(), "golang"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if count := server.RequestCount(""); count != 1 {
+		t.Fatalf("got %d requests, want 1", count)
+	}
+}
+
+func TestRetryPolicyHonorsRetryAfter(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithRetryPolicy(fastRetryPolicy(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.RateLimited(time.Second))
+	start := time.Now()
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("retried after %v, want at least the 1s Retry-After", elapsed)
+	}
+}
+
+func TestRetryPolicyStopsBeforeDeadline(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithRetryPolicy(fastRetryPolicy(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.RateLimited(time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.Search(ctx, "golang")
+	if !metaphor.IsRateLimited(err) {
+		t.Fatalf("got %v, want the rate limit error", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("waited %v for a Retry-After past the deadline", elapsed)
+	}
+}
+
+func TestRetryPolicyRetriesNetworkErrors(t *testing.T) {
+	server := newTestServer(t, 3)
+
+	var calls atomic.Int32
+	transport := metaphor.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	client, err := server.NewClient(metaphor.WithTransport(transport), metaphor.WithRetryPolicy(fastRetryPolicy(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("got %d attempts, want 2", got)
+	}
+}
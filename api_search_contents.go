@@ -0,0 +1,137 @@
+package metaphor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MissingContentsError lists the results left without contents by
+// SearchAndContents and FindSimilarAndContents.
+type MissingContentsError struct {
+	// IDs are the IDs of the results without contents, in result order.
+	IDs []string
+
+	// Err is the error of the contents request, or ErrNoContentExtracted when
+	// the request succeeded but did not return some of the documents.
+	Err error
+}
+
+// Error implements the error interface.
+func (missingErr *MissingContentsError) Error() string {
+	return fmt.Sprintf("no contents for %d results (%s): %s", len(missingErr.IDs), strings.Join(missingErr.IDs, ", "), missingErr.Err)
+}
+
+// Unwrap returns ErrGetContentsFailed and the cause of the missing contents.
+func (missingErr *MissingContentsError) Unwrap() []error {
+	return []error{ErrGetContentsFailed, missingErr.Err}
+}
+
+// SearchAndContents performs a search and returns the results with their
+// contents in a single call. The contents are requested inline with the
+// search, and results returned without contents are completed with a
+// batched contents request. By default the text of the results is
+// requested, WithText, WithHighlights and WithSummary select other contents.
+//
+// If some contents cannot be retrieved, the call fails with a
+// *MissingContentsError, unless WithPartialContents is given, in which case
+// every result is returned alongside the error.
+//
+// Parameters:
+// - ctx: The context.Context for the requests.
+// - query: The search query.
+// - options: The optional client options, applied to both the search and the contents requests.
+//
+// Returns:
+// - *SearchResponse: The search response with the contents of the results.
+// - error: An error if the search or the contents retrieval fails.
+func (client *Client) SearchAndContents(ctx context.Context, query string, options ...ClientOptions) (*SearchResponse, error) {
+	searchResults, err := client.Search(ctx, query, withInlineContents(options)...)
+	if err != nil {
+		return searchResults, err
+	}
+
+	return client.completeContents(ctx, searchResults, options)
+}
+
+// FindSimilarAndContents finds links similar to url and returns them with
+// their contents in a single call, like SearchAndContents.
+//
+// Parameters:
+// - ctx: The context.Context for the requests.
+// - url: The URL to search for similar items.
+// - options: Optional client options, applied to both the find similar and the contents requests.
+//
+// Returns:
+// - *SearchResponse: The response with the contents of the similar links.
+// - error: An error if the request or the contents retrieval fails.
+func (client *Client) FindSimilarAndContents(ctx context.Context, url string, options ...ClientOptions) (*SearchResponse, error) {
+	searchResults, err := client.FindSimilar(ctx, url, withInlineContents(options)...)
+	if err != nil {
+		return searchResults, err
+	}
+
+	return client.completeContents(ctx, searchResults, options)
+}
+
+// withInlineContents returns options asking for the text of the results to
+// be returned inline, unless the options already select the contents.
+func withInlineContents(options []ClientOptions) []ClientOptions {
+	inline := func(config *requestConfig) {
+		if config.body.Contents == nil {
+			config.body.Contents = &ContentsOptions{Text: &TextOptions{}}
+		}
+	}
+
+	return append(append([]ClientOptions(nil), options...), inline)
+}
+
+// completeContents fills the contents missing from searchResults with a
+// contents request, asking for the same contents as the inline request.
+func (client *Client) completeContents(ctx context.Context, searchResults *SearchResponse, options []ClientOptions) (*SearchResponse, error) {
+	missing := missingContents(searchResults.Results)
+	if len(missing) == 0 {
+		return searchResults, nil
+	}
+
+	var contentsErr error
+	contentsResults, err := client.GetContents(ctx, missing, withInlineContents(options)...)
+	if err != nil && !errors.Is(err, ErrNoSearchResults) {
+		contentsErr = err
+	}
+
+	contents := contentsResults.ByID()
+	for i, result := range searchResults.Results {
+		if content, ok := contents[result.ID]; ok {
+			searchResults.Results[i] = result.withContents(content)
+		}
+	}
+
+	missing = missingContents(searchResults.Results)
+	if len(missing) == 0 {
+		return searchResults, nil
+	}
+
+	if contentsErr == nil {
+		contentsErr = ErrNoContentExtracted
+	}
+
+	missingErr := &MissingContentsError{IDs: missing, Err: contentsErr}
+	if !client.newRequestConfig(RequestBody{}, options).partialContents {
+		return &SearchResponse{}, missingErr
+	}
+
+	return searchResults, missingErr
+}
+
+func missingContents(results []Result) []string {
+	missing := []string{}
+	for _, result := range results {
+		if !result.hasContents() {
+			missing = append(missing, result.ID)
+		}
+	}
+
+	return missing
+}
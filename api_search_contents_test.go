@@ -0,0 +1,167 @@
+package metaphor_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+// dropInlineContents is a middleware removing the inline text of the given
+// results from search responses, as the API does when it cannot extract it
+// in time.
+func dropInlineContents(ids ...string) metaphor.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return metaphor.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			res, err := next.RoundTrip(req)
+			if err != nil || req.URL.Path != metaphor.DefaultSearchPath || res.StatusCode != http.StatusOK {
+				return res, err
+			}
+
+			response := &metaphor.SearchResponse{}
+			err = json.NewDecoder(res.Body).Decode(response)
+			res.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			for i, result := range response.Results {
+				for _, id := range ids {
+					if result.ID == id {
+						response.Results[i].Text = ""
+					}
+				}
+			}
+
+			body, _ := json.Marshal(response)
+			res.Body = io.NopCloser(bytes.NewReader(body))
+			res.ContentLength = int64(len(body))
+
+			return res, nil
+		})
+	}
+}
+
+func TestSearchAndContentsInline(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.SearchAndContents(context.Background(), "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range response.Results {
+		if result.Text == "" {
+			t.Fatalf("%s has no text", result.ID)
+		}
+	}
+
+	request, _ := server.LastRequest()
+	if request.Path != metaphor.DefaultSearchPath || request.Body.Contents == nil || request.Body.Contents.Text == nil {
+		t.Fatalf("the text was not requested inline: %+v", request)
+	}
+
+	if count := server.RequestCount(metaphor.DefaultContentsPath); count != 0 {
+		t.Fatalf("got %d contents requests, want none", count)
+	}
+}
+
+func TestSearchAndContentsFallback(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithMiddleware(dropInlineContents("id-0", "id-2")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.SearchAndContents(context.Background(), "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range response.Results {
+		if result.Text == "" {
+			t.Fatalf("%s has no text", result.ID)
+		}
+	}
+
+	request, _ := server.LastRequest()
+	ids := append([]string(nil), request.IDs...)
+	sort.Strings(ids)
+	if request.Path != metaphor.DefaultContentsPath || !reflect.DeepEqual(ids, []string{"id-0", "id-2"}) {
+		t.Fatalf("got %s for %v, want the contents of the results without text", request.Path, request.IDs)
+	}
+
+	if request.Contents == nil || request.Contents.Text == nil {
+		t.Fatal("the fallback did not request the text")
+	}
+}
+
+func TestSearchAndContentsMissing(t *testing.T) {
+	server := newTestServer(t, 3)
+	ctx := context.Background()
+
+	client, err := server.NewClient(metaphor.WithMiddleware(dropInlineContents("id-1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusBadGateway).OnPath(metaphor.DefaultContentsPath))
+	response, err := client.SearchAndContents(ctx, "golang")
+
+	var missingErr *metaphor.MissingContentsError
+	if !errors.As(err, &missingErr) || !reflect.DeepEqual(missingErr.IDs, []string{"id-1"}) {
+		t.Fatalf("got %v, want the missing contents of id-1", err)
+	}
+
+	var apiErr *metaphor.APIError
+	if !errors.Is(err, metaphor.ErrGetContentsFailed) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("%v does not wrap the contents error", err)
+	}
+
+	if len(response.Results) != 0 {
+		t.Fatalf("got %d results without WithPartialContents", len(response.Results))
+	}
+
+	// Documents without text are reported as not extracted.
+	server.AddDocuments(metaphortest.Document{ID: "empty", URL: "https://delta.com/golang", Title: "Golang without text"})
+	response, err = client.SearchAndContents(ctx, "golang", metaphor.WithPartialContents())
+
+	if !errors.As(err, &missingErr) || !errors.Is(err, metaphor.ErrNoContentExtracted) {
+		t.Fatalf("got %v, want ErrNoContentExtracted", err)
+	}
+
+	if len(response.Results) != 4 || !reflect.DeepEqual(missingErr.IDs, []string{"empty"}) {
+		t.Fatalf("got %d results missing %v, want every result with only empty missing", len(response.Results), missingErr.IDs)
+	}
+}
+
+func TestFindSimilarAndContents(t *testing.T) {
+	server := newTestServer(t, 4)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.FindSimilarAndContents(context.Background(), "https://alpha.com/golang-0", metaphor.WithSummary(metaphor.SummaryOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range response.Results {
+		if result.Summary == "" || result.Text != "" {
+			t.Fatalf("%s holds %+v, want only the summary", result.ID, result)
+		}
+	}
+}
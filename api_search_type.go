@@ -0,0 +1,106 @@
+package metaphor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchType is the type of search performed by Search.
+type SearchType string
+
+const (
+	// SearchTypeNeural performs an embeddings based search, best suited to
+	// natural language queries.
+	SearchTypeNeural SearchType = "neural"
+
+	// SearchTypeKeyword performs a keyword based search, best suited to exact
+	// phrases, boolean queries and navigational queries.
+	SearchTypeKeyword SearchType = "keyword"
+
+	// SearchTypeAuto lets the client pick SearchTypeNeural or
+	// SearchTypeKeyword from the query, see ChooseSearchType. The chosen type
+	// is reported in SearchResponse.Type. FindSimilar has no query, and sends
+	// no type instead.
+	SearchTypeAuto SearchType = "auto"
+)
+
+// IsValid reports whether searchType is one of the known search types.
+func (searchType SearchType) IsValid() bool {
+	switch searchType {
+	case SearchTypeNeural, SearchTypeKeyword, SearchTypeAuto:
+		return true
+	default:
+		return false
+	}
+}
+
+// booleanOperators are the query words that denote a boolean keyword query.
+var booleanOperators = map[string]bool{"AND": true, "OR": true, "NOT": true}
+
+// questionWords start queries written in natural language.
+var questionWords = map[string]bool{
+	"who": true, "what": true, "when": true, "where": true, "why": true,
+	"how": true, "which": true, "is": true, "are": true, "can": true,
+}
+
+// ChooseSearchType picks the search type best suited to query, as used by
+// SearchTypeAuto. Queries with quoted phrases, boolean operators, required or
+// excluded terms, search operators such as "site:", and short navigational
+// queries such as a domain or a name use SearchTypeKeyword. Everything else,
+// in particular questions and longer descriptions, uses SearchTypeNeural.
+//
+// Parameters:
+// - query: the search query.
+//
+// Returns:
+// - SearchType: SearchTypeNeural or SearchTypeKeyword.
+func ChooseSearchType(query string) SearchType {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return SearchTypeNeural
+	}
+
+	if strings.Count(query, "\"") >= 2 {
+		return SearchTypeKeyword
+	}
+
+	words := strings.Fields(query)
+	for _, word := range words {
+		if booleanOperators[word] {
+			return SearchTypeKeyword
+		}
+
+		if len(word) > 1 && (word[0] == '+' || word[0] == '-') {
+			return SearchTypeKeyword
+		}
+
+		if operator, _, found := strings.Cut(word, ":"); found && isSearchOperator(operator) {
+			return SearchTypeKeyword
+		}
+	}
+
+	if len(words) <= 2 && !questionWords[strings.ToLower(words[0])] && !strings.HasSuffix(query, "?") {
+		return SearchTypeKeyword
+	}
+
+	return SearchTypeNeural
+}
+
+func isSearchOperator(word string) bool {
+	if word == "" {
+		return false
+	}
+
+	for _, char := range word {
+		if !unicode.IsLetter(char) {
+			return false
+		}
+	}
+
+	switch strings.ToLower(word) {
+	case "site", "intitle", "inurl", "filetype":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,86 @@
+package metaphor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+)
+
+func TestChooseSearchType(t *testing.T) {
+	tests := []struct {
+		query string
+		want  metaphor.SearchType
+	}{
+		{query: "", want: metaphor.SearchTypeNeural},
+		{query: `"exact phrase" golang`, want: metaphor.SearchTypeKeyword},
+		{query: "golang AND rust", want: metaphor.SearchTypeKeyword},
+		{query: "golang -java tutorials for beginners", want: metaphor.SearchTypeKeyword},
+		{query: "site:go.dev generics tutorial", want: metaphor.SearchTypeKeyword},
+		{query: "time: a history of clocks", want: metaphor.SearchTypeNeural},
+		{query: "go.dev", want: metaphor.SearchTypeKeyword},
+		{query: "Robert Downey", want: metaphor.SearchTypeKeyword},
+		{query: "Who is RDJ?", want: metaphor.SearchTypeNeural},
+		{query: "what is golang", want: metaphor.SearchTypeNeural},
+		{query: "golang?", want: metaphor.SearchTypeNeural},
+		{query: "a blog post explaining goroutines to beginners", want: metaphor.SearchTypeNeural},
+	}
+
+	for _, test := range tests {
+		if got := metaphor.ChooseSearchType(test.query); got != test.want {
+			t.Errorf("ChooseSearchType(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestSearchTypeAutoReportsChosenType(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithType(metaphor.SearchTypeAuto))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for query, want := range map[string]metaphor.SearchType{
+		"golang":                          metaphor.SearchTypeKeyword,
+		"what is the golang memory model": metaphor.SearchTypeNeural,
+	} {
+		response, err := client.Search(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request, _ := server.LastRequest()
+		if request.Body.Type != want || response.Type != want {
+			t.Fatalf("%q: sent %q and reported %q, want %q", query, request.Body.Type, response.Type, want)
+		}
+	}
+}
+
+func TestSearchTypeAutoIsNotSentByFindSimilar(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient(metaphor.WithType(metaphor.SearchTypeAuto))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.FindSimilar(context.Background(), "https://alpha.com/golang-0"); err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := server.LastRequest()
+	if request.Body.Type != "" {
+		t.Fatalf("sent type %q to %s", request.Body.Type, request.Path)
+	}
+}
+
+func TestSearchTypeIsValid(t *testing.T) {
+	for _, searchType := range []metaphor.SearchType{metaphor.SearchTypeNeural, metaphor.SearchTypeKeyword, metaphor.SearchTypeAuto} {
+		if !searchType.IsValid() {
+			t.Errorf("%q should be valid", searchType)
+		}
+	}
+
+	if metaphor.SearchType("Neural").IsValid() {
+		t.Error("search types are case sensitive")
+	}
+}
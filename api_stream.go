@@ -0,0 +1,206 @@
+package metaphor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// StreamItem is a search result paired with its contents, as delivered by
+// SearchStream and FindSimilarStream. When Err is set, the item reports a
+// failure: Result is set if only the contents of that result could not be
+// retrieved, and is empty if the search itself failed.
+type StreamItem struct {
+	Result  Result
+	Content Content
+	Err     error
+}
+
+// SearchStream searches for query and streams the results paired with their
+// contents. Contents are fetched in batches, concurrently, as soon as search
+// results arrive, so the first items are delivered before the search is
+// complete. Up to limit results are streamed, paginating like SearchIter.
+// Each batch is a GetContents call, seen by the hooks and the instrumentation
+// of the client.
+//
+// The channel is buffered with WithStreamBufferSize, and the pipeline stops
+// fetching when the buffer is full. Errors are delivered as items, and the
+// channel is closed when the stream ends. The caller must either drain the
+// channel or cancel ctx.
+//
+// Items are delivered in batch completion order, which may differ from the
+// order of the search results.
+//
+// Parameters:
+// - ctx: The context.Context for the requests.
+// - query: The search query.
+// - limit: The maximum number of results to stream, zero or less means no limit.
+// - options: The optional client options, applied to both the search and the contents requests.
+//
+// Returns:
+// - <-chan StreamItem: The stream of results and contents.
+func (client *Client) SearchStream(ctx context.Context, query string, limit int, options ...ClientOptions) <-chan StreamItem {
+	return client.stream(ctx, client.SearchIter(ctx, query, limit, options...), options)
+}
+
+// FindSimilarStream finds links similar to url and streams them paired with
+// their contents, like SearchStream.
+//
+// Parameters:
+// - ctx: The context.Context for the requests.
+// - url: The URL to search for similar items.
+// - limit: The maximum number of results to stream, zero or less means no limit.
+// - options: Optional client options, applied to both the find similar and the contents requests.
+//
+// Returns:
+// - <-chan StreamItem: The stream of results and contents.
+func (client *Client) FindSimilarStream(ctx context.Context, url string, limit int, options ...ClientOptions) <-chan StreamItem {
+	return client.stream(ctx, client.FindSimilarIter(ctx, url, limit, options...), options)
+}
+
+// stream reads results from iter, groups them into batches sent to a pool of
+// workers fetching their contents, and delivers the items on the returned
+// channel. A partial batch is flushed whenever iter would request a new
+// page, so results are never held back waiting for the next page.
+func (client *Client) stream(ctx context.Context, iter *ResultIterator, options []ClientOptions) <-chan StreamItem {
+	config := client.newRequestConfig(RequestBody{}, options)
+	config.idempotent = true
+
+	bufferSize := config.streamBufferSize
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+
+	batchSize := config.contentsBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultContentsBatchSize
+	}
+
+	workers := config.contentsConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	items := make(chan StreamItem, bufferSize)
+	batches := make(chan []Result, workers)
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(batches)
+
+		batch := []Result{}
+		flush := func() bool {
+			if len(batch) == 0 {
+				return true
+			}
+
+			select {
+			case batches <- batch:
+				batch = []Result{}
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			if len(iter.buffer) == 0 && !flush() {
+				return
+			}
+
+			if !iter.Next() {
+				break
+			}
+
+			batch = append(batch, iter.Result())
+			if len(batch) >= batchSize && !flush() {
+				return
+			}
+		}
+
+		if !flush() {
+			return
+		}
+
+		if err := iter.Err(); err != nil {
+			sendStreamItem(ctx, items, StreamItem{Err: err})
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				for _, item := range client.streamBatch(ctx, config, batch) {
+					if !sendStreamItem(ctx, items, item) {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(items)
+	}()
+
+	return items
+}
+
+// streamBatch fetches the contents of a batch of results and pairs them.
+// Each batch is a GetContents call of its own, running the hooks and the
+// instrumentation of the client. Results whose contents could not be
+// retrieved carry an error.
+func (client *Client) streamBatch(ctx context.Context, config *requestConfig, batch []Result) []StreamItem {
+	ids := make([]string, 0, len(batch))
+	for _, result := range batch {
+		ids = append(ids, result.ID)
+	}
+
+	// Hooks modify the config of the call, which is shared by the workers.
+	batchConfig := *config
+
+	contents, err := client.getContentsCall(ctx, &batchConfig, ids)
+	if errors.Is(err, ErrNoSearchResults) {
+		err = nil
+	}
+	if contents == nil {
+		contents = &ContentsResponse{}
+	}
+
+	byID := contents.ByID()
+
+	items := make([]StreamItem, 0, len(batch))
+	for _, result := range batch {
+		item := StreamItem{Result: result}
+
+		content, ok := byID[result.ID]
+		switch {
+		case ok:
+			item.Content = content
+		case err != nil:
+			item.Err = err
+		default:
+			item.Err = fmt.Errorf("%w: %s", ErrNoContentExtracted, result.ID)
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func sendStreamItem(ctx context.Context, items chan<- StreamItem, item StreamItem) bool {
+	select {
+	case items <- item:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,199 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+func TestSearchStreamPairsContents(t *testing.T) {
+	server := newPagingServer(t, 20, true)
+	client, err := server.NewClient(
+		metaphor.WithNumResults(5),
+		metaphor.WithContentsBatchSize(3),
+		metaphor.WithContentsConcurrency(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	for item := range client.SearchStream(context.Background(), "golang", 12) {
+		if item.Err != nil {
+			t.Fatal(item.Err)
+		}
+
+		if item.Content.ID != item.Result.ID || item.Content.Title != item.Result.Title {
+			t.Fatalf("result %s was paired with content %s", item.Result.ID, item.Content.ID)
+		}
+
+		if seen[item.Result.ID] {
+			t.Fatalf("%s was streamed twice", item.Result.ID)
+		}
+		seen[item.Result.ID] = true
+	}
+
+	if len(seen) != 12 {
+		t.Fatalf("got %d items, want 12", len(seen))
+	}
+
+	for _, request := range server.Requests() {
+		if request.Path == metaphor.DefaultContentsPath && len(request.IDs) > 3 {
+			t.Fatalf("a contents batch holds %d IDs, want at most 3", len(request.IDs))
+		}
+	}
+}
+
+func TestSearchStreamDeliversErrors(t *testing.T) {
+	server := newPagingServer(t, 4, true)
+	client, err := server.NewClient(metaphor.WithNumResults(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusBadGateway).OnPath(metaphor.DefaultContentsPath))
+
+	failed := 0
+	for item := range client.SearchStream(context.Background(), "golang", 4) {
+		if item.Result.ID == "" {
+			t.Fatalf("unexpected search error %v", item.Err)
+		}
+
+		if item.Err != nil {
+			if !errors.Is(item.Err, metaphor.ErrGetContentsFailed) {
+				t.Fatalf("got %v, want ErrGetContentsFailed", item.Err)
+			}
+			failed++
+		}
+	}
+
+	if failed != 4 {
+		t.Fatalf("got %d failed items, want the 4 results of the failed batch", failed)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusBadRequest).OnPath(metaphor.DefaultSearchPath))
+
+	items := []metaphor.StreamItem{}
+	for item := range client.SearchStream(context.Background(), "golang", 4) {
+		items = append(items, item)
+	}
+
+	if len(items) != 1 || items[0].Err == nil || items[0].Result.ID != "" {
+		t.Fatalf("got %+v, want a single search error", items)
+	}
+}
+
+func TestSearchStreamClosesOnCancel(t *testing.T) {
+	server := newPagingServer(t, 20, true)
+	client, err := server.NewClient(metaphor.WithNumResults(2), metaphor.WithStreamBufferSize(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := client.SearchStream(ctx, "golang", 0)
+	if item := <-stream; item.Err != nil {
+		t.Fatal(item.Err)
+	}
+	cancel()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-stream:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("the stream was not closed after cancel")
+		}
+	}
+}
+
+func TestFindSimilarStream(t *testing.T) {
+	server := newPagingServer(t, 5, true)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for item := range client.FindSimilarStream(context.Background(), "https://site0.com/golang", 0) {
+		if item.Err != nil {
+			t.Fatal(item.Err)
+		}
+		if item.Content.ID != item.Result.ID {
+			t.Fatalf("result %s was paired with content %s", item.Result.ID, item.Content.ID)
+		}
+		count++
+	}
+
+	if count != 4 {
+		t.Fatalf("got %d items, want 4", count)
+	}
+}
+
+// callCounter is an Instrumentation counting the calls of each operation.
+type callCounter struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (counter *callCounter) StartCall(ctx context.Context, call metaphor.CallInfo) (context.Context, func(metaphor.CallOutcome)) {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	counter.calls[call.Operation]++
+
+	return ctx, func(metaphor.CallOutcome) {}
+}
+
+func (counter *callCounter) StartRequest(ctx context.Context, request metaphor.RequestInfo) (context.Context, func(metaphor.RequestOutcome)) {
+	return ctx, func(metaphor.RequestOutcome) {}
+}
+
+func TestSearchStreamRunsContentsCalls(t *testing.T) {
+	server := newPagingServer(t, 6, true)
+	counter := &callCounter{calls: map[string]int{}}
+	client, err := server.NewClient(
+		metaphor.WithInstrumentation(counter),
+		metaphor.WithBeforeRequest(func(ctx context.Context, call *metaphor.Call) error {
+			call.Header.Set("X-Corr", "corr-"+call.Operation)
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for item := range client.SearchStream(context.Background(), "golang", 6, metaphor.WithContentsBatchSize(2)) {
+		if item.Err != nil {
+			t.Fatal(item.Err)
+		}
+	}
+
+	contentsRequests := 0
+	for _, request := range server.Requests() {
+		if request.Path != metaphor.DefaultContentsPath {
+			continue
+		}
+		contentsRequests++
+
+		if got := request.Header.Get("X-Corr"); got != "corr-GetContents" {
+			t.Fatalf("a contents request has X-Corr %q, want the header of the hook", got)
+		}
+	}
+
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	if contentsRequests == 0 || counter.calls["GetContents"] != contentsRequests {
+		t.Fatalf("got %d GetContents calls for %d contents requests", counter.calls["GetContents"], contentsRequests)
+	}
+}
@@ -0,0 +1,117 @@
+package metaphor_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+// testDomains are the domains of the documents of newTestServer.
+var testDomains = []string{"alpha.com", "beta.com", "gamma.com"}
+
+// newTestServer starts a metaphortest.Server serving n documents about
+// golang, spread over testDomains.
+func newTestServer(t *testing.T, n int) *metaphortest.Server {
+	t.Helper()
+
+	documents := make([]metaphortest.Document, 0, n)
+	for i := 0; i < n; i++ {
+		domain := testDomains[i%len(testDomains)]
+		documents = append(documents, metaphortest.Document{
+			ID:            fmt.Sprintf("id-%d", i),
+			URL:           fmt.Sprintf("https://%s/golang-%d", domain, i),
+			Title:         fmt.Sprintf("Golang article %d", i),
+			PublishedDate: fmt.Sprintf("2023-01-%02d", i%28+1),
+			Extract:       fmt.Sprintf("Golang is a programming language. Article %d is about concurrency.", i),
+		})
+	}
+
+	server := metaphortest.NewServer(documents...)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// resultDomain returns the domain of the URL of result, or "" if it is
+// invalid.
+func resultDomain(result metaphor.Result) string {
+	parsed, err := url.Parse(result.URL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(parsed.Hostname(), "www.")
+}
+
+func TestClientConcurrentCalls(t *testing.T) {
+	server := newTestServer(t, 30)
+
+	client, err := server.NewClient(metaphor.WithNumResults(4), metaphor.WithAutoprompt(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const goroutines = 32
+	const callsPerGoroutine = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*callsPerGoroutine)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < callsPerGoroutine; i++ {
+				domain := testDomains[(g+i)%len(testDomains)]
+				numResults := 1 + (g+i)%5
+
+				var options []metaphor.ClientOptions
+				wantResults := 4
+				if i%2 == 0 {
+					options = append(options, metaphor.WithNumResults(numResults), metaphor.WithIncludeDomains([]string{domain}))
+					wantResults = numResults
+				}
+
+				response, err := client.Search(context.Background(), "golang", options...)
+				if err != nil {
+					errs <- err
+					continue
+				}
+
+				if len(response.Results) != wantResults {
+					errs <- fmt.Errorf("call %d/%d: got %d results, want %d", g, i, len(response.Results), wantResults)
+				}
+
+				if i%2 == 0 {
+					for _, result := range response.Results {
+						if got := resultDomain(result); got != domain {
+							errs <- fmt.Errorf("call %d/%d: got a result from %s, want only %s", g, i, got, domain)
+						}
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for _, request := range server.Requests() {
+		if request.Body.UseAutoprompt {
+			t.Fatalf("client default was lost: %+v", request.Body)
+		}
+
+		if len(request.Body.IncludeDomains) == 0 && request.Body.NumResults != 4 {
+			t.Fatalf("per-call options leaked into a call without them: %+v", request.Body)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package metaphor
+
+import "net/http"
+
+// Middleware wraps the http.RoundTripper used by the client. Middlewares can
+// be used to add authentication proxies, instrumentation or any other
+// behavior that needs to see every HTTP request sent to the Metaphor API.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter that allows the use of ordinary functions
+// as http.RoundTripper, typically when writing a Middleware.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newHTTPClient builds the http.Client used by a Client from its
+// configuration. The http.Client passed with WithHTTPClient is copied so the
+// caller's value is never modified, the transport set with WithTransport
+// replaces its transport, the timeout set with WithTimeout replaces its
+// timeout, and the middlewares are applied on top so that the first
+// middleware is the outermost one.
+func newHTTPClient(config *requestConfig) *http.Client {
+	httpClient := &http.Client{}
+	if config.httpClient != nil {
+		*httpClient = *config.httpClient
+	}
+
+	if config.transport != nil {
+		httpClient.Transport = config.transport
+	}
+
+	if config.timeout > 0 {
+		httpClient.Timeout = config.timeout
+	}
+
+	if len(config.middlewares) == 0 {
+		return httpClient
+	}
+
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	for i := len(config.middlewares) - 1; i >= 0; i-- {
+		transport = config.middlewares[i](transport)
+	}
+	httpClient.Transport = transport
+
+	return httpClient
+}
@@ -0,0 +1,129 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+)
+
+// countingTransport counts the requests it sends with http.DefaultTransport.
+type countingTransport struct {
+	requests atomic.Int64
+}
+
+func (transport *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	server := newTestServer(t, 3)
+	calls := []string{}
+	middleware := func(name string) metaphor.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return metaphor.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				req.Header.Add("X-Middleware", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client, err := server.NewClient(
+		metaphor.WithMiddleware(middleware("first"), middleware("second")),
+		metaphor.WithMiddleware(middleware("third")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"first", "second", "third"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("middlewares were called in order %v, want %v", calls, want)
+	}
+
+	request, _ := server.LastRequest()
+	if got := request.Header.Values("X-Middleware"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("the request went through %v", got)
+	}
+}
+
+func TestWithHTTPClientIsNotModified(t *testing.T) {
+	server := newTestServer(t, 3)
+	transport := &countingTransport{}
+	httpClient := &http.Client{Transport: transport, Timeout: time.Minute}
+
+	client, err := server.NewClient(
+		metaphor.WithHTTPClient(httpClient),
+		metaphor.WithTimeout(time.Second),
+		metaphor.WithMiddleware(func(next http.RoundTripper) http.RoundTripper { return next }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if transport.requests.Load() != 1 {
+		t.Fatal("the request was not sent with the transport of the http.Client")
+	}
+
+	if httpClient.Transport != transport || httpClient.Timeout != time.Minute {
+		t.Fatalf("the http.Client was modified: %+v", httpClient)
+	}
+}
+
+func TestWithTransportReplacesHTTPClientTransport(t *testing.T) {
+	server := newTestServer(t, 3)
+	replaced := &countingTransport{}
+	transport := &countingTransport{}
+
+	client, err := server.NewClient(
+		metaphor.WithHTTPClient(&http.Client{Transport: replaced}),
+		metaphor.WithTransport(transport),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if transport.requests.Load() != 1 || replaced.requests.Load() != 0 {
+		t.Fatalf("sent %d requests with WithTransport and %d with the http.Client transport", transport.requests.Load(), replaced.requests.Load())
+	}
+}
+
+func TestWithTimeoutOverridesHTTPClientTimeout(t *testing.T) {
+	server := newTestServer(t, 3)
+	server.SetLatency(500 * time.Millisecond)
+
+	client, err := server.NewClient(
+		metaphor.WithHTTPClient(&http.Client{Timeout: time.Hour}),
+		metaphor.WithTimeout(20*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Search(context.Background(), "golang")
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("got %v, want a timeout", err)
+	}
+}
@@ -0,0 +1,180 @@
+package metaphor
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FieldError is a problem with a single field of a request.
+type FieldError struct {
+	// Field is the JSON name of the field.
+	Field string
+
+	// Err describes the problem.
+	Err error
+}
+
+// Error implements the error interface.
+func (fieldErr FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Err)
+}
+
+// ValidationError lists every problem found when validating a request. It is
+// returned by the Validate methods, and by Search, FindSimilar and
+// GetContents before any request is sent.
+type ValidationError struct {
+	Problems []FieldError
+}
+
+// Error implements the error interface.
+func (validationErr *ValidationError) Error() string {
+	problems := make([]string, 0, len(validationErr.Problems))
+	for _, problem := range validationErr.Problems {
+		problems = append(problems, problem.Error())
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidRequest, strings.Join(problems, "; "))
+}
+
+// Unwrap returns ErrInvalidRequest and the errors of every problem, so that
+// errors.Is(err, ErrInvalidRequest) and errors.Is(err, ErrInvalidDate) work.
+func (validationErr *ValidationError) Unwrap() []error {
+	errs := []error{ErrInvalidRequest}
+	for _, problem := range validationErr.Problems {
+		errs = append(errs, problem.Err)
+	}
+
+	return errs
+}
+
+// add records a problem with field.
+func (validationErr *ValidationError) add(field string, err error) {
+	validationErr.Problems = append(validationErr.Problems, FieldError{Field: field, Err: err})
+}
+
+// errorOrNil returns validationErr if it holds problems, and nil otherwise.
+func (validationErr *ValidationError) errorOrNil() error {
+	if len(validationErr.Problems) == 0 {
+		return nil
+	}
+
+	return validationErr
+}
+
+// Validate checks the request for problems the API would reject, or that
+// would silently return unexpected results.
+//
+// Returns:
+// - error: A *ValidationError listing every problem, or nil if the request is valid.
+func (body RequestBody) Validate() error {
+	validationErr := &ValidationError{}
+
+	if body.Query == "" && body.URL == "" {
+		validationErr.add("query", errors.New("a query or a url is required"))
+	}
+
+	if body.URL != "" {
+		if err := validateURL(body.URL); err != nil {
+			validationErr.add("url", err)
+		}
+	}
+
+	if body.NumResults < 0 {
+		validationErr.add("numResults", fmt.Errorf("must not be negative, got %d", body.NumResults))
+	}
+
+	if len(body.IncludeDomains) > 0 && len(body.ExcludeDomains) > 0 {
+		validationErr.add("includeDomains", errors.New("only one of includeDomains and excludeDomains can be specified"))
+	}
+
+	validateDomains(validationErr, "includeDomains", body.IncludeDomains)
+	validateDomains(validationErr, "excludeDomains", body.ExcludeDomains)
+
+	if body.Type != "" && !body.Type.IsValid() {
+		validationErr.add("type", fmt.Errorf("must be %q, %q or %q, got %q", SearchTypeNeural, SearchTypeKeyword, SearchTypeAuto, body.Type))
+	}
+
+	crawlStart := validateDate(validationErr, "startCrawlDate", body.StartCrawlDate)
+	crawlEnd := validateDate(validationErr, "endCrawlDate", body.EndCrawlDate)
+	validateDateRange(validationErr, "startCrawlDate", crawlStart, crawlEnd)
+
+	publishedStart := validateDate(validationErr, "startPublishedDate", body.StartPublishedDate)
+	publishedEnd := validateDate(validationErr, "endPublishedDate", body.EndPublishedDate)
+	validateDateRange(validationErr, "startPublishedDate", publishedStart, publishedEnd)
+
+	validateContentsOptions(validationErr, "contents.", body.Contents)
+
+	return validationErr.errorOrNil()
+}
+
+// Validate checks that the request holds at least one ID, no empty ID and
+// valid contents options.
+//
+// Returns:
+// - error: A *ValidationError listing every problem, or nil if the request is valid.
+func (body ContentsRequestBody) Validate() error {
+	validationErr := &ValidationError{}
+
+	if len(body.IDs) == 0 {
+		validationErr.add("ids", errors.New("at least one ID is required"))
+	}
+
+	for i, id := range body.IDs {
+		if strings.TrimSpace(id) == "" {
+			validationErr.add(fmt.Sprintf("ids[%d]", i), errors.New("must not be empty"))
+		}
+	}
+
+	validateContentsOptions(validationErr, "", body.ContentsOptions)
+
+	return validationErr.errorOrNil()
+}
+
+func validateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("must be an absolute http or https URL, got %q", rawURL)
+	}
+
+	if parsed.Host == "" {
+		return fmt.Errorf("must have a host, got %q", rawURL)
+	}
+
+	return nil
+}
+
+func validateDomains(validationErr *ValidationError, field string, domains []string) {
+	for i, domain := range domains {
+		if strings.TrimSpace(domain) == "" {
+			validationErr.add(fmt.Sprintf("%s[%d]", field, i), errors.New("must not be empty"))
+		}
+	}
+}
+
+// validateDate parses the date of a field, recording a problem if it is
+// invalid. It returns the zero time when the date is missing or invalid.
+func validateDate(validationErr *ValidationError, field string, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+
+	date, err := parseDate(value)
+	if err != nil {
+		validationErr.add(field, err)
+	}
+
+	return date
+}
+
+func validateDateRange(validationErr *ValidationError, field string, start time.Time, end time.Time) {
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		validationErr.add(field, fmt.Errorf("%w: %s is not before %s", ErrInvalidDateRange, formatDate(start), formatDate(end)))
+	}
+}
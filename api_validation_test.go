@@ -0,0 +1,141 @@
+package metaphor_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+)
+
+func problemFields(err error) []string {
+	var validationErr *metaphor.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+
+	fields := []string{}
+	for _, problem := range validationErr.Problems {
+		fields = append(fields, problem.Field)
+	}
+
+	return fields
+}
+
+func TestRequestBodyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   metaphor.RequestBody
+		fields []string
+	}{
+		{
+			name: "valid search",
+			body: metaphor.RequestBody{Query: "golang", NumResults: 10, Type: metaphor.SearchTypeNeural, StartPublishedDate: "2023-01-01", EndPublishedDate: "2023-12-31"},
+		},
+		{
+			name: "valid find similar",
+			body: metaphor.RequestBody{URL: "https://go.dev", ExcludeDomains: []string{"example.com"}},
+		},
+		{
+			name:   "missing query",
+			body:   metaphor.RequestBody{},
+			fields: []string{"query"},
+		},
+		{
+			name:   "relative url",
+			body:   metaphor.RequestBody{URL: "go.dev/doc"},
+			fields: []string{"url"},
+		},
+		{
+			name:   "negative results and unknown type",
+			body:   metaphor.RequestBody{Query: "golang", NumResults: -1, Type: "semantic"},
+			fields: []string{"numResults", "type"},
+		},
+		{
+			name:   "both domain lists with an empty domain",
+			body:   metaphor.RequestBody{Query: "golang", IncludeDomains: []string{"go.dev"}, ExcludeDomains: []string{" "}},
+			fields: []string{"includeDomains", "excludeDomains[0]"},
+		},
+		{
+			name:   "invalid and reversed dates",
+			body:   metaphor.RequestBody{Query: "golang", StartCrawlDate: "yesterday", StartPublishedDate: "2023-12-31", EndPublishedDate: "2023-01-01"},
+			fields: []string{"startCrawlDate", "startPublishedDate"},
+		},
+		{
+			name: "negative contents options",
+			body: metaphor.RequestBody{Query: "golang", Contents: &metaphor.ContentsOptions{
+				Text:       &metaphor.TextOptions{MaxCharacters: -1},
+				Highlights: &metaphor.HighlightsOptions{NumSentences: -1, HighlightsPerURL: -1},
+			}},
+			fields: []string{"contents.text.maxCharacters", "contents.highlights.numSentences", "contents.highlights.highlightsPerUrl"},
+		},
+	}
+
+	for _, test := range tests {
+		err := test.body.Validate()
+		if test.fields == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+
+		if !errors.Is(err, metaphor.ErrInvalidRequest) {
+			t.Errorf("%s: got %v, want ErrInvalidRequest", test.name, err)
+		}
+
+		if got := problemFields(err); !reflect.DeepEqual(got, test.fields) {
+			t.Errorf("%s: got problems with %v, want %v", test.name, got, test.fields)
+		}
+	}
+}
+
+func TestValidateWrapsDateErrors(t *testing.T) {
+	err := metaphor.RequestBody{Query: "golang", StartCrawlDate: "2023-13-45", StartPublishedDate: "2023-02-01", EndPublishedDate: "2023-01-01"}.Validate()
+
+	if !errors.Is(err, metaphor.ErrInvalidDate) || !errors.Is(err, metaphor.ErrInvalidDateRange) {
+		t.Fatalf("%v does not wrap the date errors", err)
+	}
+}
+
+func TestContentsRequestBodyValidate(t *testing.T) {
+	if err := (metaphor.ContentsRequestBody{IDs: []string{"a"}}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := metaphor.ContentsRequestBody{}.Validate()
+	if got := problemFields(err); !reflect.DeepEqual(got, []string{"ids"}) {
+		t.Fatalf("got problems with %v", got)
+	}
+
+	err = metaphor.ContentsRequestBody{IDs: []string{"a", ""}}.Validate()
+	if got := problemFields(err); !reflect.DeepEqual(got, []string{"ids[1]"}) {
+		t.Fatalf("got problems with %v", got)
+	}
+}
+
+func TestInvalidCallsAreNotSent(t *testing.T) {
+	server := newTestServer(t, 3)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.Search(ctx, "golang", metaphor.WithNumResults(-3)); !errors.Is(err, metaphor.ErrInvalidRequest) || !errors.Is(err, metaphor.ErrSearchFailed) {
+		t.Fatalf("got %v", err)
+	}
+
+	if _, err := client.FindSimilar(ctx, "not a url"); !errors.Is(err, metaphor.ErrInvalidRequest) {
+		t.Fatalf("got %v", err)
+	}
+
+	if _, err := client.GetContents(ctx, nil); !errors.Is(err, metaphor.ErrInvalidRequest) {
+		t.Fatalf("got %v", err)
+	}
+
+	if count := server.RequestCount(""); count != 0 {
+		t.Fatalf("%d invalid requests were sent", count)
+	}
+}
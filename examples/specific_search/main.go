@@ -26,7 +26,7 @@ func main() {
 	//
 	reqOptions := metaphor.RequestOptions {
 		StartCrawlDate: "2023-01-01",
-		EndCrawlDate: "2023-12-32",
+		EndCrawlDate: "2023-12-31",
 		ExcludeDomains: []string{"www.wikipedia.com"},
 	}
 	
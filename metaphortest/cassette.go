@@ -0,0 +1,279 @@
+package metaphortest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// ErrUnmatchedRequest is returned by a Replayer for requests that are not in
+// its cassette.
+var ErrUnmatchedRequest = errors.New("metaphortest: no recorded interaction matches the request")
+
+// redactedHeaders lists the headers never written to a cassette.
+var redactedHeaders = []string{"x-api-key", "Authorization"}
+
+// Cassette holds recorded API interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the request of an Interaction.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the response of an Interaction.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// LoadCassette reads a cassette written by a Recorder.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := &Cassette{}
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("metaphortest: invalid cassette %s: %w", path, err)
+	}
+
+	return cassette, nil
+}
+
+// Save writes the cassette to path.
+func (cassette *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Recorder is an http.RoundTripper sending requests with another transport
+// and writing every exchange to a cassette file, with the API key redacted.
+// Pass it to metaphor.WithTransport to record a client.
+type Recorder struct {
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a Recorder writing to the cassette at path, which is
+// overwritten. Requests are sent with next, or http.DefaultTransport when
+// next is nil.
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Recorder{path: path, next: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, outgoing, err := recordRequest(req)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	res, err := recorder.next.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: res.StatusCode,
+			Header:     res.Header.Clone(),
+			Body:       string(body),
+		},
+	})
+
+	if err := recorder.cassette.Save(recorder.path); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Replayer is an http.RoundTripper serving the responses of a cassette. It
+// matches requests by method, path, query and canonical JSON body; identical
+// requests are answered with their recorded responses in order. Requests
+// without a matching interaction fail with ErrUnmatchedRequest.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer creates a Replayer serving the cassette at path.
+func NewReplayer(path string) (*Replayer, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replayer{
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (replayer *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, _, err := recordRequest(req)
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	replayer.mu.Lock()
+	defer replayer.mu.Unlock()
+
+	for i, interaction := range replayer.interactions {
+		if replayer.used[i] || !matches(interaction.Request, recorded) {
+			continue
+		}
+		replayer.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s?%s %s", ErrUnmatchedRequest, recorded.Method, recorded.Path, recorded.Query, recorded.Body)
+}
+
+// Unused returns the interactions that were not replayed, which usually
+// means the code under test sent fewer requests than when it was recorded.
+func (replayer *Replayer) Unused() []Interaction {
+	replayer.mu.Lock()
+	defer replayer.mu.Unlock()
+
+	unused := []Interaction{}
+	for i, interaction := range replayer.interactions {
+		if !replayer.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+
+	return unused
+}
+
+// recordRequest captures req with its headers redacted and its body
+// canonicalized, and returns the request to send in its place. req is never
+// modified: its body is read with GetBody when possible, and otherwise
+// consumed and replaced in a clone of req.
+func recordRequest(req *http.Request) (RecordedRequest, *http.Request, error) {
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query().Encode(),
+		Header: req.Header.Clone(),
+	}
+
+	for _, header := range redactedHeaders {
+		if recorded.Header.Get(header) != "" {
+			recorded.Header.Set(header, "REDACTED")
+		}
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return recorded, req, nil
+	}
+
+	outgoing := req
+	var body io.ReadCloser
+	if req.GetBody != nil {
+		var err error
+		if body, err = req.GetBody(); err != nil {
+			return recorded, req, err
+		}
+	} else {
+		body = req.Body
+	}
+
+	content, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return recorded, req, err
+	}
+
+	if req.GetBody == nil {
+		outgoing = req.Clone(req.Context())
+		outgoing.Body = io.NopCloser(bytes.NewReader(content))
+	}
+	recorded.Body = canonicalJSON(content)
+
+	return recorded, outgoing, nil
+}
+
+func matches(recorded RecordedRequest, req RecordedRequest) bool {
+	return recorded.Method == req.Method &&
+		recorded.Path == req.Path &&
+		recorded.Query == req.Query &&
+		canonicalJSON([]byte(recorded.Body)) == req.Body
+}
+
+// canonicalJSON re-encodes a JSON body with sorted object keys, so that the
+// field order does not matter when matching. Other bodies are kept as is.
+func canonicalJSON(body []byte) string {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return string(body)
+	}
+
+	return string(canonical)
+}
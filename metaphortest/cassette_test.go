@@ -0,0 +1,143 @@
+package metaphortest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	server := metaphortest.NewServer(corpus...)
+	defer server.Close()
+	server.ExpectAPIKey("secret-key")
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := metaphortest.NewRecorder(path, nil)
+	client := newClient(t, server, metaphor.WithTransport(recorder))
+
+	recorded, err := client.Search(ctx, "golang", metaphor.WithNumResults(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetContents(ctx, []string{"go-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Fatal("the API key was written to the cassette")
+	}
+
+	cassette, err := metaphortest.LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cassette.Interactions) != 2 || cassette.Interactions[0].Request.Header.Get("x-api-key") != "REDACTED" {
+		t.Fatalf("unexpected cassette %+v", cassette)
+	}
+
+	// Replay without the server.
+	server.Close()
+	replayer, err := metaphortest.NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := metaphor.NewClient("other-key", metaphor.WithBaseURL(server.URL), metaphor.WithTransport(replayer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := replayed.Search(ctx, "golang", metaphor.WithNumResults(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Results) != len(recorded.Results) || response.Results[0].ID != recorded.Results[0].ID {
+		t.Fatalf("replayed %+v, recorded %+v", response.Results, recorded.Results)
+	}
+
+	if _, err := replayed.GetContents(ctx, []string{"go-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Fatalf("%d interactions were not replayed", len(unused))
+	}
+
+	// Each interaction is only replayed once, and other requests do not match.
+	for _, numResults := range []int{2, 3} {
+		_, err := replayed.Search(ctx, "golang", metaphor.WithNumResults(numResults))
+		if !errors.Is(err, metaphortest.ErrUnmatchedRequest) {
+			t.Fatalf("got %v, want ErrUnmatchedRequest", err)
+		}
+	}
+}
+
+// trackedBody is a request body that records whether it was closed.
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (body *trackedBody) Close() error {
+	body.closed = true
+	return nil
+}
+
+func TestRecorderDoesNotModifyRequests(t *testing.T) {
+	server := metaphortest.NewServer(corpus...)
+	defer server.Close()
+
+	recorder := metaphortest.NewRecorder(filepath.Join(t.TempDir(), "cassette.json"), nil)
+
+	// A body without GetBody is consumed, closed and sent from a clone.
+	body := &trackedBody{Reader: strings.NewReader(`{"query": "golang"}`)}
+	req, err := http.NewRequest(http.MethodPost, server.URL+metaphor.DefaultSearchPath, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := recorder.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if req.Body != body || !body.closed {
+		t.Fatalf("the request body was replaced or left open")
+	}
+
+	// A body with GetBody is read from a copy and sent as is.
+	req, err = http.NewRequest(http.MethodPost, server.URL+metaphor.DefaultSearchPath, strings.NewReader(`{"query": "golang"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := req.Body
+
+	res, err = recorder.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if req.Body != original {
+		t.Fatal("the request body was replaced")
+	}
+
+	for _, request := range server.Requests() {
+		if request.Body.Query != "golang" {
+			t.Fatalf("the server received %+v", request.Body)
+		}
+	}
+}
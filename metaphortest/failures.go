@@ -0,0 +1,81 @@
+package metaphortest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Failure is a scripted failure, see Server.FailNext.
+type Failure struct {
+	// Path restricts the failure to one endpoint, such as
+	// metaphor.DefaultSearchPath. Empty matches every endpoint.
+	Path string
+
+	// StatusCode is the status of the response. Zero serves the normal
+	// response, which is useful to only add Latency.
+	StatusCode int
+
+	// Header holds extra response headers, such as Retry-After.
+	Header http.Header
+
+	// Body is the raw response body. When empty, a JSON error response with
+	// the status text is sent.
+	Body string
+
+	// Latency delays the response, on top of the Server latency.
+	Latency time.Duration
+}
+
+// StatusFailure returns a Failure answering with statusCode and a JSON error
+// response.
+func StatusFailure(statusCode int) Failure {
+	return Failure{StatusCode: statusCode}
+}
+
+// RateLimited returns a Failure answering with a 429 status and a
+// Retry-After header of retryAfter, rounded up to the second.
+func RateLimited(retryAfter time.Duration) Failure {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+
+	return Failure{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{strconv.Itoa(seconds)}},
+	}
+}
+
+// MalformedJSON returns a Failure answering with a 200 status and a body
+// that is not valid JSON.
+func MalformedJSON() Failure {
+	return Failure{
+		StatusCode: http.StatusOK,
+		Body:       `{"results": [`,
+	}
+}
+
+// Latency returns a Failure that only delays the normal response.
+func Latency(latency time.Duration) Failure {
+	return Failure{Latency: latency}
+}
+
+// OnPath returns a copy of the failure restricted to path.
+func (failure Failure) OnPath(path string) Failure {
+	failure.Path = path
+	return failure
+}
+
+func (failure Failure) write(w http.ResponseWriter) {
+	for key, values := range failure.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+
+	if failure.Body == "" {
+		writeError(w, failure.StatusCode, http.StatusText(failure.StatusCode))
+		return
+	}
+
+	w.WriteHeader(failure.StatusCode)
+	w.Write([]byte(failure.Body))
+}
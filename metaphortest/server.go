@@ -0,0 +1,566 @@
+// Package metaphortest provides an in-process fake of the Metaphor API for
+// testing code built on the metaphor client without reaching the network.
+//
+// A Server serves the search, find similar and contents endpoints from a
+// programmable corpus of documents, can be scripted to fail or slow down,
+// and records every request it receives:
+//
+//	server := metaphortest.NewServer(metaphortest.Document{
+//		ID:      "doc-1",
+//		URL:     "https://example.com/rdj",
+//		Title:   "Who is RDJ?",
+//		Extract: "Robert Downey Jr. is an actor.",
+//	})
+//	defer server.Close()
+//
+//	server.FailNext(1, metaphortest.RateLimited(time.Second))
+//
+//	client, err := metaphor.NewClient("test-key", metaphor.WithBaseURL(server.URL))
+package metaphortest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+)
+
+// Document is a document of the fake corpus.
+type Document struct {
+	ID            string
+	URL           string
+	Title         string
+	PublishedDate string
+	CrawlDate     string
+	Author        string
+	Extract       string
+}
+
+// Request is a request received by the Server.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+
+	// Body is the decoded body of search and find similar requests.
+	Body metaphor.RequestBody
+
+	// IDs holds the IDs of contents requests.
+	IDs []string
+
+	// Contents holds the contents options of POST contents requests.
+	Contents *metaphor.ContentsOptions
+}
+
+// Server is a fake Metaphor API. It embeds an *httptest.Server, so its URL
+// can be passed to metaphor.WithBaseURL. A Server is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	documents []Document
+	failures  []Failure
+	latency   time.Duration
+	apiKey    string
+	requests  []Request
+}
+
+// NewServer starts a Server serving the given documents. The caller must
+// call Close when done.
+func NewServer(documents ...Document) *Server {
+	server := &Server{documents: append([]Document(nil), documents...)}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+
+	return server
+}
+
+// NewClient creates a metaphor client sending its requests to the Server.
+// The options are applied after the base URL option.
+func (server *Server) NewClient(options ...metaphor.ClientOptions) (*metaphor.Client, error) {
+	apiKey := server.expectedAPIKey()
+	if apiKey == "" {
+		apiKey = "metaphortest"
+	}
+
+	options = append([]metaphor.ClientOptions{metaphor.WithBaseURL(server.URL)}, options...)
+
+	return metaphor.NewClient(apiKey, options...)
+}
+
+// AddDocuments adds documents to the corpus.
+func (server *Server) AddDocuments(documents ...Document) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.documents = append(server.documents, documents...)
+}
+
+// SetLatency delays every response by latency.
+func (server *Server) SetLatency(latency time.Duration) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.latency = latency
+}
+
+// ExpectAPIKey makes the Server reject requests whose x-api-key header is
+// not apiKey with a 401 response.
+func (server *Server) ExpectAPIKey(apiKey string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.apiKey = apiKey
+}
+
+// FailNext makes the next n matching requests fail with failure. Failures are
+// consumed in the order they were scripted.
+func (server *Server) FailNext(n int, failure Failure) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		server.failures = append(server.failures, failure)
+	}
+}
+
+// Requests returns the requests received so far, in order.
+func (server *Server) Requests() []Request {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return append([]Request(nil), server.requests...)
+}
+
+// RequestCount returns the number of requests received for path, or for all
+// paths when path is empty.
+func (server *Server) RequestCount(path string) int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	count := 0
+	for _, request := range server.requests {
+		if path == "" || request.Path == path {
+			count++
+		}
+	}
+
+	return count
+}
+
+// LastRequest returns the last request received and whether there was one.
+func (server *Server) LastRequest() (Request, bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if len(server.requests) == 0 {
+		return Request{}, false
+	}
+
+	return server.requests[len(server.requests)-1], true
+}
+
+// Reset forgets the recorded requests and the scripted failures.
+func (server *Server) Reset() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.requests = nil
+	server.failures = nil
+}
+
+func (server *Server) expectedAPIKey() string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return server.apiKey
+}
+
+func (server *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	request, err := readRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	server.mu.Lock()
+	server.requests = append(server.requests, request)
+	latency := server.latency
+	apiKey := server.apiKey
+	failure, failing := server.nextFailure(request.Path)
+	documents := append([]Document(nil), server.documents...)
+	server.mu.Unlock()
+
+	if failing {
+		latency += failure.Latency
+	}
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if apiKey != "" && r.Header.Get("x-api-key") != apiKey {
+		writeError(w, http.StatusUnauthorized, "invalid API key")
+		return
+	}
+
+	if failing && failure.StatusCode != 0 {
+		failure.write(w)
+		return
+	}
+
+	switch request.Path {
+	case metaphor.DefaultSearchPath:
+		writeJSON(w, metaphor.SearchResponse{Results: search(documents, request.Body)})
+	case metaphor.DefaultFindSimilarPath:
+		writeJSON(w, metaphor.SearchResponse{Results: findSimilar(documents, request.Body)})
+	case metaphor.DefaultContentsPath:
+		writeJSON(w, metaphor.ContentsResponse{Contents: contents(documents, request.IDs, request.Contents)})
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint "+request.Path)
+	}
+}
+
+// nextFailure pops the first scripted failure matching path. It must be
+// called with the lock held.
+func (server *Server) nextFailure(path string) (Failure, bool) {
+	for i, failure := range server.failures {
+		if failure.Path == "" || failure.Path == path {
+			server.failures = append(server.failures[:i], server.failures[i+1:]...)
+			return failure, true
+		}
+	}
+
+	return Failure{}, false
+}
+
+func readRequest(r *http.Request) (Request, error) {
+	request := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return request, err
+	}
+
+	if request.Path == metaphor.DefaultContentsPath {
+		if r.Method == http.MethodPost {
+			contentsBody := metaphor.ContentsRequestBody{}
+			err = json.Unmarshal(body, &contentsBody)
+			request.IDs = contentsBody.IDs
+			request.Contents = contentsBody.ContentsOptions
+		} else {
+			request.IDs = r.URL.Query()["ids"]
+		}
+
+		return request, err
+	}
+
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &request.Body)
+	}
+
+	return request, err
+}
+
+// search scores the documents by the fraction of query terms found in their
+// title or extract, and returns the best matches passing the filters.
+func search(documents []Document, body metaphor.RequestBody) []metaphor.Result {
+	terms := strings.Fields(strings.ToLower(body.Query))
+
+	results := []metaphor.Result{}
+	for _, document := range documents {
+		if !matchesFilters(document, body) {
+			continue
+		}
+
+		text := strings.ToLower(document.Title + " " + document.Extract)
+		matched := 0
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				matched++
+			}
+		}
+
+		if matched == 0 {
+			continue
+		}
+
+		results = append(results, newResult(document, body, float64(matched)/float64(len(terms))))
+	}
+
+	return rank(results, body.NumResults)
+}
+
+// findSimilar returns the documents passing the filters other than the one at
+// the requested URL, excluding the documents of its domain when asked to.
+func findSimilar(documents []Document, body metaphor.RequestBody) []metaphor.Result {
+	sourceDomain := domain(body.URL)
+
+	results := []metaphor.Result{}
+	for _, document := range documents {
+		if document.URL == body.URL || !matchesFilters(document, body) {
+			continue
+		}
+
+		if body.ExcludeSourceDomain && domain(document.URL) == sourceDomain {
+			continue
+		}
+
+		results = append(results, newResult(document, body, 1/float64(len(results)+2)))
+	}
+
+	return rank(results, body.NumResults)
+}
+
+func contents(documents []Document, ids []string, options *metaphor.ContentsOptions) []metaphor.Content {
+	byID := make(map[string]Document, len(documents))
+	for _, document := range documents {
+		byID[document.ID] = document
+	}
+
+	found := []metaphor.Content{}
+	for _, id := range ids {
+		if document, ok := byID[id]; ok {
+			content := metaphor.Content{
+				ID:      document.ID,
+				URL:     document.URL,
+				Title:   document.Title,
+				Extract: document.Extract,
+			}
+
+			if options != nil {
+				content = newResult(document, metaphor.RequestBody{Contents: options}, 0).ToContent()
+			}
+
+			found = append(found, content)
+		}
+	}
+
+	return found
+}
+
+// newResult converts document to a result, with the contents selected by
+// the request when it asks for them inline. Like the API, inline contents
+// hold no extract.
+func newResult(document Document, body metaphor.RequestBody, score float64) metaphor.Result {
+	result := metaphor.Result{
+		ID:            document.ID,
+		URL:           document.URL,
+		Title:         document.Title,
+		PublishedDate: document.PublishedDate,
+		Author:        document.Author,
+		Score:         score,
+	}
+
+	if body.Contents != nil {
+		applyContents(&result, document, body.Query, body.Contents)
+	}
+
+	return result
+}
+
+// applyContents fills the contents of result selected by options. The text
+// is the extract, highlights are the sentences of the extract sharing the
+// most terms with the query, and the summary is its first sentence.
+func applyContents(result *metaphor.Result, document Document, query string, options *metaphor.ContentsOptions) {
+	if options.Text != nil {
+		result.Text = document.Extract
+		if options.Text.MaxCharacters > 0 && len(result.Text) > options.Text.MaxCharacters {
+			result.Text = result.Text[:options.Text.MaxCharacters]
+		}
+	}
+
+	sentences := splitSentences(document.Extract)
+
+	if options.Highlights != nil && len(sentences) > 0 {
+		if options.Highlights.Query != "" {
+			query = options.Highlights.Query
+		}
+
+		result.Highlights, result.HighlightScores = highlights(sentences, query, options.Highlights)
+	}
+
+	if options.Summary != nil && len(sentences) > 0 {
+		result.Summary = sentences[0]
+	}
+}
+
+// highlights groups sentences into highlights of the requested number of
+// sentences, and returns the best scoring ones.
+func highlights(sentences []string, query string, options *metaphor.HighlightsOptions) ([]string, []float64) {
+	numSentences := options.NumSentences
+	if numSentences <= 0 {
+		numSentences = 1
+	}
+
+	perURL := options.HighlightsPerURL
+	if perURL <= 0 {
+		perURL = 1
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+
+	type highlight struct {
+		text  string
+		score float64
+	}
+
+	candidates := []highlight{}
+	for start := 0; start < len(sentences); start += numSentences {
+		end := start + numSentences
+		if end > len(sentences) {
+			end = len(sentences)
+		}
+
+		text := strings.Join(sentences[start:end], " ")
+		matched := 0
+		for _, term := range terms {
+			if strings.Contains(strings.ToLower(text), term) {
+				matched++
+			}
+		}
+
+		score := 0.0
+		if len(terms) > 0 {
+			score = float64(matched) / float64(len(terms))
+		}
+
+		candidates = append(candidates, highlight{text: text, score: score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	if len(candidates) > perURL {
+		candidates = candidates[:perURL]
+	}
+
+	texts := make([]string, 0, len(candidates))
+	scores := make([]float64, 0, len(candidates))
+	for _, candidate := range candidates {
+		texts = append(texts, candidate.text)
+		scores = append(scores, candidate.score)
+	}
+
+	return texts, scores
+}
+
+func splitSentences(text string) []string {
+	sentences := []string{}
+	start := 0
+	for i, char := range text {
+		if char == '.' || char == '!' || char == '?' {
+			if sentence := strings.TrimSpace(text[start : i+1]); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start = i + 1
+		}
+	}
+
+	if sentence := strings.TrimSpace(text[start:]); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+
+	return sentences
+}
+
+func rank(results []metaphor.Result, numResults int) []metaphor.Result {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if numResults > 0 && len(results) > numResults {
+		results = results[:numResults]
+	}
+
+	return results
+}
+
+func matchesFilters(document Document, body metaphor.RequestBody) bool {
+	documentDomain := domain(document.URL)
+
+	if len(body.IncludeDomains) > 0 && !containsDomain(body.IncludeDomains, documentDomain) {
+		return false
+	}
+
+	if containsDomain(body.ExcludeDomains, documentDomain) {
+		return false
+	}
+
+	return inRange(document.PublishedDate, body.StartPublishedDate, body.EndPublishedDate) &&
+		inRange(document.CrawlDate, body.StartCrawlDate, body.EndCrawlDate)
+}
+
+func containsDomain(domains []string, documentDomain string) bool {
+	for _, candidate := range domains {
+		if strings.TrimPrefix(strings.ToLower(candidate), "www.") == documentDomain {
+			return true
+		}
+	}
+
+	return false
+}
+
+func domain(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// inRange reports whether date is between start and end. Missing or
+// unparsable dates are not filtered.
+func inRange(date string, start string, end string) bool {
+	value, ok := parseDate(date)
+	if !ok {
+		return true
+	}
+
+	if startDate, ok := parseDate(start); ok && value.Before(startDate) {
+		return false
+	}
+
+	if endDate, ok := parseDate(end); ok && value.After(endDate) {
+		return false
+	}
+
+	return true
+}
+
+func parseDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,249 @@
+package metaphortest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+)
+
+var corpus = []metaphortest.Document{
+	{ID: "go-1", URL: "https://go.dev/concurrency", Title: "Golang concurrency", PublishedDate: "2023-03-01", Extract: "Goroutines are cheap. Channels connect them."},
+	{ID: "go-2", URL: "https://go.dev/intro", Title: "Golang introduction", PublishedDate: "2022-06-01", Extract: "Go is a simple language."},
+	{ID: "go-3", URL: "https://blog.example.com/golang", Title: "Why I like golang", PublishedDate: "2023-09-01", Extract: "Fast builds and concurrency."},
+	{ID: "rust-1", URL: "https://rust-lang.org/book", Title: "The Rust book", PublishedDate: "2023-01-01", Extract: "Ownership and borrowing."},
+}
+
+func newClient(t *testing.T, server *metaphortest.Server, options ...metaphor.ClientOptions) *metaphor.Client {
+	t.Helper()
+
+	client, err := server.NewClient(options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func resultIDs(results []metaphor.Result) []string {
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+
+	return ids
+}
+
+func TestServerSearchRanksCorpus(t *testing.T) {
+	server := metaphortest.NewServer(corpus...)
+	defer server.Close()
+	client := newClient(t, server)
+
+	response, err := client.Search(context.Background(), "golang concurrency")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Documents matching both terms rank first, the Rust book matches none.
+	if got := resultIDs(response.Results); !reflect.DeepEqual(got, []string{"go-1", "go-3", "go-2"}) {
+		t.Fatalf("got %v", got)
+	}
+
+	if response.Results[0].Score != 1 || response.Results[2].Score != 0.5 {
+		t.Fatalf("unexpected scores %+v", response.Results)
+	}
+
+	response, err = client.Search(context.Background(), "golang", metaphor.WithNumResults(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(response.Results))
+	}
+
+	if _, err := client.Search(context.Background(), "haskell"); !errors.Is(err, metaphor.ErrNoSearchResults) {
+		t.Fatalf("got %v, want ErrNoSearchResults", err)
+	}
+}
+
+func TestServerSearchFilters(t *testing.T) {
+	server := metaphortest.NewServer(corpus...)
+	defer server.Close()
+	client := newClient(t, server)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		options []metaphor.ClientOptions
+		want    []string
+	}{
+		{
+			name:    "include domains",
+			options: []metaphor.ClientOptions{metaphor.WithIncludeDomains([]string{"www.go.dev"})},
+			want:    []string{"go-1", "go-2"},
+		},
+		{
+			name:    "exclude domains",
+			options: []metaphor.ClientOptions{metaphor.WithExcludeDomains([]string{"go.dev"})},
+			want:    []string{"go-3"},
+		},
+		{
+			name:    "published dates",
+			options: []metaphor.ClientOptions{metaphor.WithStartPublishedDate("2023-01-01"), metaphor.WithEndPublishedDate("2023-06-30")},
+			want:    []string{"go-1"},
+		},
+	}
+
+	for _, test := range tests {
+		response, err := client.Search(ctx, "golang", test.options...)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		got := resultIDs(response.Results)
+		if len(got) != len(test.want) {
+			t.Fatalf("%s: got %v, want %v", test.name, got, test.want)
+		}
+
+		for _, id := range test.want {
+			found := false
+			for _, candidate := range got {
+				found = found || candidate == id
+			}
+			if !found {
+				t.Fatalf("%s: got %v, want %v", test.name, got, test.want)
+			}
+		}
+	}
+}
+
+func TestServerFindSimilarAndContents(t *testing.T) {
+	server := metaphortest.NewServer(corpus...)
+	defer server.Close()
+	client := newClient(t, server)
+	ctx := context.Background()
+
+	response, err := client.FindSimilar(ctx, "https://go.dev/concurrency", metaphor.WithExcludeSourceDomain(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range response.Results {
+		if result.ID == "go-1" || result.ID == "go-2" {
+			t.Fatalf("got %s from the source domain", result.ID)
+		}
+	}
+
+	contents, err := client.GetContents(ctx, []string{"go-1", "missing"}, metaphor.WithSummary(metaphor.SummaryOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(contents.Contents) != 1 || contents.Contents[0].Summary != "Goroutines are cheap." {
+		t.Fatalf("unexpected contents %+v", contents.Contents)
+	}
+
+	request, _ := server.LastRequest()
+	if request.Method != http.MethodPost || request.Contents == nil || request.Contents.Summary == nil {
+		t.Fatalf("contents options were not recorded: %+v", request)
+	}
+}
+
+func TestServerFailNextOrder(t *testing.T) {
+	server := metaphortest.NewServer(corpus...)
+	defer server.Close()
+	client := newClient(t, server)
+	ctx := context.Background()
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusBadGateway).OnPath(metaphor.DefaultContentsPath))
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusServiceUnavailable))
+	server.FailNext(1, metaphortest.MalformedJSON())
+
+	statusCode := func(err error) int {
+		var apiErr *metaphor.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr.StatusCode
+		}
+		return 0
+	}
+
+	// The contents failure is skipped by searches.
+	_, err := client.Search(ctx, "golang")
+	if statusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("got %v, want a 503", err)
+	}
+
+	_, err = client.GetContents(ctx, []string{"go-1"})
+	if statusCode(err) != http.StatusBadGateway {
+		t.Fatalf("got %v, want a 502", err)
+	}
+
+	if _, err = client.Search(ctx, "golang"); err == nil || statusCode(err) != 0 {
+		t.Fatalf("got %v, want a decoding error", err)
+	}
+
+	if _, err = client.Search(ctx, "golang"); err != nil {
+		t.Fatalf("got %v after the scripted failures", err)
+	}
+
+	if count := server.RequestCount(metaphor.DefaultSearchPath); count != 3 {
+		t.Fatalf("got %d search requests, want 3", count)
+	}
+
+	server.FailNext(1, metaphortest.StatusFailure(http.StatusInternalServerError))
+	server.Reset()
+	if _, err = client.Search(ctx, "golang"); err != nil || server.RequestCount("") != 1 {
+		t.Fatalf("Reset kept the failures or the requests: %v", err)
+	}
+}
+
+func TestServerExpectAPIKey(t *testing.T) {
+	server := metaphortest.NewServer(corpus...)
+	defer server.Close()
+	server.ExpectAPIKey("secret")
+	ctx := context.Background()
+
+	wrong, err := metaphor.NewClient("wrong", metaphor.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wrong.Search(ctx, "golang"); !metaphor.IsAuthError(err) {
+		t.Fatalf("got %v, want an auth error", err)
+	}
+
+	// Server.NewClient uses the expected key.
+	if _, err := newClient(t, server).Search(ctx, "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 || requests[0].Header.Get("x-api-key") != "wrong" || requests[1].Header.Get("x-api-key") != "secret" {
+		t.Fatalf("unexpected recorded requests %+v", requests)
+	}
+}
+
+func TestServerRateLimitedIsRetried(t *testing.T) {
+	server := metaphortest.NewServer(corpus...)
+	defer server.Close()
+
+	server.FailNext(1, metaphortest.RateLimited(time.Second).OnPath(metaphor.DefaultSearchPath))
+
+	policy := metaphor.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := newClient(t, server, metaphor.WithRetryPolicy(policy))
+
+	if _, err := client.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	if server.RequestCount(metaphor.DefaultSearchPath) != 2 {
+		t.Fatal("expected the search to be retried")
+	}
+}
@@ -0,0 +1,230 @@
+// Package otelmetaphor instruments the metaphor client with OpenTelemetry
+// traces and metrics. It lives in its own module so that the client itself
+// has no dependencies:
+//
+//	instrumentation, err := otelmetaphor.New()
+//	if err != nil {
+//		// ...
+//	}
+//
+//	client, err := metaphor.NewClient(apiKey, metaphor.WithInstrumentation(instrumentation))
+//
+// Every Search, FindSimilar and GetContents call records a span, with a child
+// client span for each request sent to the API, including its retries.
+// Responses served from the cache record no request span. Calls returning
+// metaphor.ErrNoSearchResults or metaphor.ErrNoLinksFound are not counted
+// as errors.
+//
+// The following metrics are recorded:
+//   - metaphor.client.call.duration: the duration of calls, in seconds.
+//   - metaphor.client.request.duration: the duration of requests, in seconds.
+//   - metaphor.client.errors: the number of failed calls.
+package otelmetaphor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/metaphorsystems/metaphor-go/otelmetaphor"
+
+// Attribute keys recorded on spans and metrics.
+const (
+	OperationKey  = attribute.Key("metaphor.operation")
+	EndpointKey   = attribute.Key("metaphor.endpoint")
+	SearchTypeKey = attribute.Key("metaphor.search_type")
+	NumResultsKey = attribute.Key("metaphor.num_results")
+	ResultsKey    = attribute.Key("metaphor.result_count")
+	AttemptsKey   = attribute.Key("metaphor.attempts")
+	MethodKey     = attribute.Key("http.request.method")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+	ErrorTypeKey  = attribute.Key("error.type")
+)
+
+// Option configures the Instrumentation.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider sets the tracer provider.
+// Default: the global tracer provider
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(config *config) {
+		config.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider.
+// Default: the global meter provider
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(config *config) {
+		config.meterProvider = provider
+	}
+}
+
+// Instrumentation implements metaphor.Instrumentation with OpenTelemetry.
+type Instrumentation struct {
+	tracer          trace.Tracer
+	callDuration    metric.Float64Histogram
+	requestDuration metric.Float64Histogram
+	errors          metric.Int64Counter
+}
+
+var _ metaphor.Instrumentation = (*Instrumentation)(nil)
+
+// New creates an Instrumentation, to be passed to metaphor.WithInstrumentation.
+//
+// Parameters:
+// - options: the optional tracer and meter providers.
+//
+// Returns:
+// - *Instrumentation: the instrumentation.
+// - error: an error if the metric instruments cannot be created.
+func New(options ...Option) (*Instrumentation, error) {
+	config := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	meter := config.meterProvider.Meter(ScopeName)
+
+	callDuration, err := meter.Float64Histogram(
+		"metaphor.client.call.duration",
+		metric.WithDescription("Duration of Search, FindSimilar and GetContents calls."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	requestDuration, err := meter.Float64Histogram(
+		"metaphor.client.request.duration",
+		metric.WithDescription("Duration of requests to the Metaphor API, including retries."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	errorCount, err := meter.Int64Counter(
+		"metaphor.client.errors",
+		metric.WithDescription("Number of failed Search, FindSimilar and GetContents calls."),
+		metric.WithUnit("{error}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instrumentation{
+		tracer:          config.tracerProvider.Tracer(ScopeName),
+		callDuration:    callDuration,
+		requestDuration: requestDuration,
+		errors:          errorCount,
+	}, nil
+}
+
+// StartCall starts the span of a call.
+func (instrumentation *Instrumentation) StartCall(ctx context.Context, call metaphor.CallInfo) (context.Context, func(metaphor.CallOutcome)) {
+	attributes := []attribute.KeyValue{
+		OperationKey.String(call.Operation),
+		EndpointKey.String(call.Endpoint),
+	}
+
+	spanAttributes := append([]attribute.KeyValue{NumResultsKey.Int(call.NumResults)}, attributes...)
+	if call.SearchType != "" {
+		spanAttributes = append(spanAttributes, SearchTypeKey.String(string(call.SearchType)))
+	}
+
+	ctx, span := instrumentation.tracer.Start(ctx, "metaphor."+call.Operation,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(spanAttributes...),
+	)
+
+	return ctx, func(outcome metaphor.CallOutcome) {
+		defer span.End()
+
+		span.SetAttributes(ResultsKey.Int(outcome.Results))
+
+		err := outcome.Err
+		if errors.Is(err, metaphor.ErrNoSearchResults) || errors.Is(err, metaphor.ErrNoLinksFound) {
+			err = nil
+		}
+
+		if err != nil {
+			errorType := errorType(err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.SetAttributes(ErrorTypeKey.String(errorType))
+
+			instrumentation.errors.Add(ctx, 1, metric.WithAttributes(append(attributes, ErrorTypeKey.String(errorType))...))
+		}
+
+		instrumentation.callDuration.Record(ctx, outcome.Duration.Seconds(), metric.WithAttributes(attributes...))
+	}
+}
+
+// StartRequest starts the client span of a request.
+func (instrumentation *Instrumentation) StartRequest(ctx context.Context, request metaphor.RequestInfo) (context.Context, func(metaphor.RequestOutcome)) {
+	ctx, span := instrumentation.tracer.Start(ctx, request.Method+" "+request.Endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			MethodKey.String(request.Method),
+			EndpointKey.String(request.Endpoint),
+		),
+	)
+
+	return ctx, func(outcome metaphor.RequestOutcome) {
+		defer span.End()
+
+		attributes := []attribute.KeyValue{
+			MethodKey.String(request.Method),
+			EndpointKey.String(request.Endpoint),
+		}
+		if outcome.StatusCode != 0 {
+			attributes = append(attributes, StatusCodeKey.Int(outcome.StatusCode))
+		}
+
+		span.SetAttributes(append(attributes, AttemptsKey.Int(outcome.Attempts))...)
+
+		if outcome.Err != nil {
+			span.RecordError(outcome.Err)
+			span.SetStatus(codes.Error, outcome.Err.Error())
+			span.SetAttributes(ErrorTypeKey.String(errorType(outcome.Err)))
+		}
+
+		instrumentation.requestDuration.Record(ctx, outcome.Duration.Seconds(), metric.WithAttributes(attributes...))
+	}
+}
+
+// errorType classifies err for the error.type attribute: the HTTP status
+// code for API errors, and a short name otherwise.
+func errorType(err error) string {
+	var apiErr *metaphor.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return strconv.Itoa(apiErr.StatusCode)
+	case errors.Is(err, metaphor.ErrInvalidRequest):
+		return "invalid_request"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "_OTHER"
+	}
+}
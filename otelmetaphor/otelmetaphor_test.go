@@ -0,0 +1,243 @@
+package otelmetaphor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/metaphorsystems/metaphor-go"
+	"github.com/metaphorsystems/metaphor-go/metaphortest"
+	"github.com/metaphorsystems/metaphor-go/otelmetaphor"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type harness struct {
+	server   *metaphortest.Server
+	client   *metaphor.Client
+	recorder *tracetest.SpanRecorder
+	reader   *sdkmetric.ManualReader
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	server := metaphortest.NewServer(
+		metaphortest.Document{ID: "go-1", URL: "https://go.dev/concurrency", Title: "Golang concurrency"},
+		metaphortest.Document{ID: "go-2", URL: "https://go.dev/intro", Title: "Golang introduction"},
+		metaphortest.Document{ID: "go-3", URL: "https://blog.example.com/golang", Title: "Why I like golang"},
+	)
+	t.Cleanup(server.Close)
+
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	instrumentation, err := otelmetaphor.New(
+		otelmetaphor.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		otelmetaphor.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := server.NewClient(metaphor.WithInstrumentation(instrumentation))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &harness{server: server, client: client, recorder: recorder, reader: reader}
+}
+
+func (harness *harness) metric(t *testing.T, name string) metricdata.Metrics {
+	t.Helper()
+
+	var data metricdata.ResourceMetrics
+	if err := harness.reader.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scope := range data.ScopeMetrics {
+		if scope.Scope.Name != otelmetaphor.ScopeName {
+			continue
+		}
+		for _, metric := range scope.Metrics {
+			if metric.Name == name {
+				return metric
+			}
+		}
+	}
+
+	t.Fatalf("no %s metric was recorded", name)
+	return metricdata.Metrics{}
+}
+
+func attributeValue(attributes []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
+
+func histogramCount(t *testing.T, metric metricdata.Metrics, attributes ...attribute.KeyValue) uint64 {
+	t.Helper()
+
+	histogram, ok := metric.Data.(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("%s is a %T, want a float64 histogram", metric.Name, metric.Data)
+	}
+
+	want := attribute.NewSet(attributes...)
+	for _, point := range histogram.DataPoints {
+		if point.Attributes.Equals(&want) {
+			return point.Count
+		}
+	}
+
+	return 0
+}
+
+func TestSearchSpans(t *testing.T) {
+	harness := newHarness(t)
+
+	response, err := harness.client.Search(context.Background(), "golang",
+		metaphor.WithType(metaphor.SearchTypeKeyword),
+		metaphor.WithNumResults(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := harness.recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want the call and its request", len(spans))
+	}
+
+	// The request span ends before the call span.
+	request, call := spans[0], spans[1]
+	if call.Name() != "metaphor.Search" || request.Name() != "POST /search" {
+		t.Fatalf("got spans %q and %q", call.Name(), request.Name())
+	}
+
+	if request.Parent().SpanID() != call.SpanContext().SpanID() {
+		t.Fatal("the request span is not a child of the call span")
+	}
+
+	callAttributes := map[attribute.Key]attribute.Value{
+		otelmetaphor.OperationKey:  attribute.StringValue("Search"),
+		otelmetaphor.EndpointKey:   attribute.StringValue(metaphor.DefaultSearchPath),
+		otelmetaphor.SearchTypeKey: attribute.StringValue(string(metaphor.SearchTypeKeyword)),
+		otelmetaphor.NumResultsKey: attribute.IntValue(2),
+		otelmetaphor.ResultsKey:    attribute.IntValue(len(response.Results)),
+	}
+	for key, want := range callAttributes {
+		if got, ok := attributeValue(call.Attributes(), key); !ok || got != want {
+			t.Errorf("call span %s = %v, want %v", key, got.Emit(), want.Emit())
+		}
+	}
+
+	requestAttributes := map[attribute.Key]attribute.Value{
+		otelmetaphor.MethodKey:     attribute.StringValue(http.MethodPost),
+		otelmetaphor.EndpointKey:   attribute.StringValue(metaphor.DefaultSearchPath),
+		otelmetaphor.StatusCodeKey: attribute.IntValue(http.StatusOK),
+		otelmetaphor.AttemptsKey:   attribute.IntValue(1),
+	}
+	for key, want := range requestAttributes {
+		if got, ok := attributeValue(request.Attributes(), key); !ok || got != want {
+			t.Errorf("request span %s = %v, want %v", key, got.Emit(), want.Emit())
+		}
+	}
+
+	if call.Status().Code == codes.Error || request.Status().Code == codes.Error {
+		t.Fatal("a successful call has an error status")
+	}
+}
+
+func TestFailedCallSpan(t *testing.T) {
+	harness := newHarness(t)
+	harness.server.FailNext(1, metaphortest.StatusFailure(http.StatusBadRequest))
+
+	if _, err := harness.client.Search(context.Background(), "golang"); err == nil {
+		t.Fatal("expected the search to fail")
+	}
+
+	spans := harness.recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want the call and its request", len(spans))
+	}
+
+	for _, span := range spans {
+		if span.Status().Code != codes.Error {
+			t.Errorf("%s has status %v, want an error", span.Name(), span.Status().Code)
+		}
+
+		if got, _ := attributeValue(span.Attributes(), otelmetaphor.ErrorTypeKey); got.AsString() != "400" {
+			t.Errorf("%s has error.type %q, want 400", span.Name(), got.AsString())
+		}
+
+		if len(span.Events()) == 0 {
+			t.Errorf("%s did not record the error", span.Name())
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	harness := newHarness(t)
+	ctx := context.Background()
+
+	if _, err := harness.client.Search(ctx, "golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	harness.server.FailNext(1, metaphortest.StatusFailure(http.StatusBadRequest))
+	if _, err := harness.client.Search(ctx, "golang"); err == nil {
+		t.Fatal("expected the search to fail")
+	}
+
+	// Searches without results are not errors.
+	if _, err := harness.client.Search(ctx, "haskell"); !errors.Is(err, metaphor.ErrNoSearchResults) {
+		t.Fatalf("got %v, want ErrNoSearchResults", err)
+	}
+
+	search := []attribute.KeyValue{
+		otelmetaphor.OperationKey.String("Search"),
+		otelmetaphor.EndpointKey.String(metaphor.DefaultSearchPath),
+	}
+	if count := histogramCount(t, harness.metric(t, "metaphor.client.call.duration"), search...); count != 3 {
+		t.Fatalf("got %d call durations, want 3", count)
+	}
+
+	requests := harness.metric(t, "metaphor.client.request.duration")
+	request := []attribute.KeyValue{
+		otelmetaphor.MethodKey.String(http.MethodPost),
+		otelmetaphor.EndpointKey.String(metaphor.DefaultSearchPath),
+	}
+	if count := histogramCount(t, requests, append(request, otelmetaphor.StatusCodeKey.Int(http.StatusOK))...); count != 2 {
+		t.Fatalf("got %d successful request durations, want 2", count)
+	}
+	if count := histogramCount(t, requests, append(request, otelmetaphor.StatusCodeKey.Int(http.StatusBadRequest))...); count != 1 {
+		t.Fatalf("got %d failed request durations, want 1", count)
+	}
+
+	errorMetric := harness.metric(t, "metaphor.client.errors")
+	sum, ok := errorMetric.Data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("metaphor.client.errors is a %T, want an int64 sum", errorMetric.Data)
+	}
+
+	if len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 1 {
+		t.Fatalf("got error data points %+v, want a single error", sum.DataPoints)
+	}
+
+	want := attribute.NewSet(append(search, otelmetaphor.ErrorTypeKey.String("400"))...)
+	if !sum.DataPoints[0].Attributes.Equals(&want) {
+		t.Fatalf("the error was counted with %v", sum.DataPoints[0].Attributes.ToSlice())
+	}
+}